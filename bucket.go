@@ -0,0 +1,100 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// ErrLateRecord is returned by Aggregator.Add for a record that belongs to a
+// bucket that has already been sealed.
+var ErrLateRecord = errors.New("whirlpool: record belongs to a sealed bucket")
+
+// Bucket is the sealed digest of the records of one time interval.
+type Bucket struct {
+	Start   time.Time // Start of the interval.
+	Records uint64    // Number of records in the interval.
+	Sum     []byte    // Whirlpool checksum of the records.
+}
+
+// Aggregator accumulates timestamped records into per-interval digests. The
+// digest of an interval is the whirlpool checksum of its records in arrival
+// order, each prefixed with its length as a 64-bit big-endian integer.
+//
+// Intervals are aligned with time.Truncate. A bucket is sealed and passed to
+// the emit function as soon as a record for a later interval arrives, or
+// when Flush is called. Intervals without records produce no bucket.
+type Aggregator struct {
+	interval time.Duration
+	emit     func(Bucket)
+
+	open    bool
+	start   time.Time
+	records uint64
+	w       whirlpool
+
+	sealed     bool      // Whether a bucket has been sealed.
+	lastSealed time.Time // Start of the last sealed bucket.
+}
+
+// NewAggregator returns an Aggregator with buckets of the given interval
+// that passes sealed buckets to emit. It panics if interval is not positive
+// or emit is nil.
+func NewAggregator(interval time.Duration, emit func(Bucket)) *Aggregator {
+	if interval <= 0 {
+		panic("whirlpool: aggregation interval must be positive")
+	}
+	if emit == nil {
+		panic("whirlpool: nil emit function")
+	}
+	return &Aggregator{interval: interval, emit: emit}
+}
+
+// Add adds a record with timestamp t. Records must arrive in interval order;
+// a record for an interval before the current one, or for an interval that
+// has already been sealed, is rejected with ErrLateRecord.
+func (a *Aggregator) Add(t time.Time, record []byte) error {
+	start := t.Truncate(a.interval)
+	if a.sealed && !start.After(a.lastSealed) {
+		return ErrLateRecord
+	}
+	if a.open {
+		if start.Before(a.start) {
+			return ErrLateRecord
+		}
+		if start.After(a.start) {
+			a.Flush()
+		}
+	}
+	if !a.open {
+		a.open = true
+		a.start = start
+	}
+
+	var l [8]byte
+	binary.BigEndian.PutUint64(l[:], uint64(len(record)))
+	a.w.Write(l[:])
+	a.w.Write(record)
+	a.records++
+	return nil
+}
+
+// Flush seals and emits the current bucket, if any. Records added afterwards
+// for the same interval are rejected with ErrLateRecord, so every interval
+// produces at most one bucket.
+func (a *Aggregator) Flush() {
+	if !a.open {
+		return
+	}
+	b := Bucket{Start: a.start, Records: a.records, Sum: a.w.Sum(nil)}
+	a.open = false
+	a.sealed = true
+	a.lastSealed = a.start
+	a.records = 0
+	a.w.Reset()
+	a.emit(b)
+}
@@ -0,0 +1,103 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestAggregator(t *testing.T) {
+	var buckets []whirlpool.Bucket
+	a := whirlpool.NewAggregator(time.Minute, func(b whirlpool.Bucket) {
+		buckets = append(buckets, b)
+	})
+
+	t0 := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	add := func(d time.Duration, rec string) {
+		t.Helper()
+		if err := a.Add(t0.Add(d), []byte(rec)); err != nil {
+			t.Fatalf("Add(%v, %q): %v", d, rec, err)
+		}
+	}
+	add(5*time.Second, "a")
+	add(59*time.Second, "bc")
+	if len(buckets) != 0 {
+		t.Fatalf("bucket sealed early")
+	}
+	add(3*time.Minute, "d")
+	if err := a.Add(t0.Add(time.Minute), []byte("late")); err != whirlpool.ErrLateRecord {
+		t.Fatalf("late record: got %v want ErrLateRecord", err)
+	}
+	a.Flush()
+	a.Flush()
+
+	if len(buckets) != 2 {
+		t.Fatalf("got %d buckets want 2", len(buckets))
+	}
+
+	want := sum([]byte("\x00\x00\x00\x00\x00\x00\x00\x01a\x00\x00\x00\x00\x00\x00\x00\x02bc"))
+	if b := buckets[0]; !b.Start.Equal(t0) || b.Records != 2 || !bytes.Equal(b.Sum, want) {
+		t.Fatalf("first bucket = %+v want start %v, 2 records, sum %x", b, t0, want)
+	}
+	want = sum([]byte("\x00\x00\x00\x00\x00\x00\x00\x01d"))
+	if b := buckets[1]; !b.Start.Equal(t0.Add(3*time.Minute)) || b.Records != 1 || !bytes.Equal(b.Sum, want) {
+		t.Fatalf("second bucket = %+v", b)
+	}
+}
+
+func TestAggregatorAddAfterFlush(t *testing.T) {
+	var buckets []whirlpool.Bucket
+	a := whirlpool.NewAggregator(time.Minute, func(b whirlpool.Bucket) {
+		buckets = append(buckets, b)
+	})
+	t0 := time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC)
+
+	if err := a.Add(t0, []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	a.Flush()
+	if err := a.Add(t0.Add(30*time.Second), []byte("b")); err != whirlpool.ErrLateRecord {
+		t.Fatalf("record for a flushed interval: got %v want ErrLateRecord", err)
+	}
+	if err := a.Add(t0.Add(-time.Minute), []byte("c")); err != whirlpool.ErrLateRecord {
+		t.Fatalf("record before a flushed interval: got %v want ErrLateRecord", err)
+	}
+	if err := a.Add(t0.Add(time.Minute), []byte("d")); err != nil {
+		t.Fatalf("record for the next interval: %v", err)
+	}
+	a.Flush()
+
+	if len(buckets) != 2 || !buckets[0].Start.Equal(t0) || !buckets[1].Start.Equal(t0.Add(time.Minute)) {
+		t.Fatalf("got buckets %+v", buckets)
+	}
+	if buckets[0].Records != 1 || buckets[1].Records != 1 {
+		t.Fatalf("got buckets %+v", buckets)
+	}
+}
+
+func TestAggregatorInvalid(t *testing.T) {
+	emit := func(whirlpool.Bucket) {}
+	for _, c := range []struct {
+		interval time.Duration
+		emit     func(whirlpool.Bucket)
+	}{
+		{0, emit},
+		{-time.Second, emit},
+		{time.Minute, nil},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewAggregator(%v, nil emit %v) did not panic", c.interval, c.emit == nil)
+				}
+			}()
+			whirlpool.NewAggregator(c.interval, c.emit)
+		}()
+	}
+}
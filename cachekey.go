@@ -0,0 +1,59 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"encoding/hex"
+	"strconv"
+)
+
+// CacheKey builds stable cache keys from a namespace, a version and any
+// number of parts.
+//
+// The key has the form "namespace:vVERSION:HEX", where HEX is the lowercase
+// hex whirlpool checksum of the namespace, the version and the parts in the
+// order they were added, encoded as in HashStruct: strings and byte slices
+// as a type tag, their length as a 64-bit big-endian integer and their
+// bytes, the version and integers as a type tag and a 64-bit big-endian
+// integer. Bumping the version invalidates all keys of the namespace.
+type CacheKey struct {
+	namespace string
+	version   uint64
+	w         whirlpool
+	e         encoder
+}
+
+// NewCacheKey starts a cache key in the given namespace and version.
+func NewCacheKey(namespace string, version uint64) *CacheKey {
+	k := &CacheKey{namespace: namespace, version: version}
+	k.e.w = &k.w
+	k.e.string(namespace)
+	k.e.tagUint64(tagUint, version)
+	return k
+}
+
+// AddString adds a string part to the key.
+func (k *CacheKey) AddString(s string) *CacheKey {
+	k.e.string(s)
+	return k
+}
+
+// AddBytes adds a byte slice part to the key.
+func (k *CacheKey) AddBytes(b []byte) *CacheKey {
+	k.e.bytes(tagBytes, b)
+	return k
+}
+
+// AddInt adds an integer part to the key.
+func (k *CacheKey) AddInt(i int64) *CacheKey {
+	k.e.tagUint64(tagInt, uint64(i))
+	return k
+}
+
+// Key returns the cache key for the parts added so far. More parts can be
+// added afterwards.
+func (k *CacheKey) Key() string {
+	return k.namespace + ":v" + strconv.FormatUint(k.version, 10) + ":" + hex.EncodeToString(k.w.Sum(nil))
+}
@@ -0,0 +1,41 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestCacheKey(t *testing.T) {
+	k := whirlpool.NewCacheKey("thumbs", 2).AddString("img.png").AddInt(128).Key()
+
+	hexSum, ok := strings.CutPrefix(k, "thumbs:v2:")
+	if !ok {
+		t.Fatalf("key %q lacks namespace and version prefix", k)
+	}
+	if b, err := hex.DecodeString(hexSum); err != nil || len(b) != 64 {
+		t.Fatalf("key %q does not end in a hex digest", k)
+	}
+	if again := whirlpool.NewCacheKey("thumbs", 2).AddString("img.png").AddInt(128).Key(); again != k {
+		t.Fatalf("key not stable: %q != %q", again, k)
+	}
+
+	others := []string{
+		whirlpool.NewCacheKey("thumbs", 3).AddString("img.png").AddInt(128).Key(),
+		whirlpool.NewCacheKey("thumb", 2).AddString("img.png").AddInt(128).Key(),
+		whirlpool.NewCacheKey("thumbs", 2).AddString("img.pn").AddString("g").AddInt(128).Key(),
+		whirlpool.NewCacheKey("thumbs", 2).AddBytes([]byte("img.png")).AddInt(128).Key(),
+		whirlpool.NewCacheKey("thumbs", 2).AddInt(128).AddString("img.png").Key(),
+	}
+	for _, o := range others {
+		if strings.HasSuffix(o, hexSum) {
+			t.Fatalf("different parts produced the same digest: %q", o)
+		}
+	}
+}
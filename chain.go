@@ -0,0 +1,55 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"bufio"
+	"io"
+	"iter"
+)
+
+// ChainState is the whirlpool chaining value after a number of whole
+// blocks have been hashed.
+type ChainState struct {
+	Blocks uint64                  // Number of blocks hashed so far.
+	State  [digestBytes / 8]uint64 // Chaining value after those blocks.
+}
+
+// Chain returns an iterator that hashes r and yields the chaining state
+// after every k blocks. A trailing partial block is hashed but not yielded,
+// since the chaining value only changes on block boundaries.
+//
+// If reading from r fails, the error is yielded with a zero ChainState and
+// the iteration stops.
+func Chain(r io.Reader, k int) iter.Seq2[ChainState, error] {
+	if k < 1 {
+		k = 1
+	}
+	return func(yield func(ChainState, error) bool) {
+		var (
+			w      whirlpool
+			block  [wblockBytes]byte
+			blocks uint64
+		)
+		br := bufio.NewReaderSize(r, 32*1024)
+		for {
+			n, err := io.ReadFull(br, block[:])
+			w.Write(block[:n])
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return
+			}
+			if err != nil {
+				yield(ChainState{}, err)
+				return
+			}
+			blocks++
+			if blocks%uint64(k) == 0 {
+				if !yield(ChainState{Blocks: blocks, State: w.hash}, nil) {
+					return
+				}
+			}
+		}
+	}
+}
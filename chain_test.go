@@ -0,0 +1,83 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestChain(t *testing.T) {
+	data := make([]byte, 64*10+17)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	var all []whirlpool.ChainState
+	for cs, err := range whirlpool.Chain(bytes.NewReader(data), 1) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, cs)
+	}
+	if len(all) != 10 {
+		t.Fatalf("got %d states want 10", len(all))
+	}
+
+	var every3 []whirlpool.ChainState
+	for cs, err := range whirlpool.Chain(bytes.NewReader(data), 3) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		every3 = append(every3, cs)
+	}
+	if len(every3) != 3 {
+		t.Fatalf("got %d states want 3", len(every3))
+	}
+	for i, cs := range every3 {
+		if want := all[3*i+2]; cs != want {
+			t.Fatalf("state after %d blocks = %v want %v", cs.Blocks, cs, want)
+		}
+	}
+
+	// The chaining value only depends on the blocks already read.
+	var prefix whirlpool.ChainState
+	for cs := range whirlpool.Chain(bytes.NewReader(data[:64*4]), 4) {
+		prefix = cs
+	}
+	if prefix != all[3] {
+		t.Fatalf("prefix state = %v want %v", prefix, all[3])
+	}
+}
+
+func TestChainBreak(t *testing.T) {
+	n := 0
+	for range whirlpool.Chain(bytes.NewReader(make([]byte, 64*8)), 1) {
+		n++
+		if n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Fatalf("iterated %d times want 2", n)
+	}
+}
+
+func TestChainError(t *testing.T) {
+	boom := errors.New("boom")
+	r := io.MultiReader(bytes.NewReader(make([]byte, 64)), iotest.ErrReader(boom))
+	var got error
+	for _, err := range whirlpool.Chain(r, 1) {
+		got = err
+	}
+	if !errors.Is(got, boom) {
+		t.Fatalf("got error %v want %v", got, boom)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const chunkMapMagic = "whc\x01"
+
+// ChunkMap tracks which chunks of a partially downloaded file have been
+// verified against their expected whirlpool checksums, such as the extents
+// returned by SumExtents. It can be persisted with MarshalBinary next to the
+// partial file, so that a download manager can resume later, check the
+// chunks it already has with Revalidate and fetch only the missing ones.
+type ChunkMap struct {
+	size      int64
+	chunkSize int64
+	sums      []Digest
+	bitmap    []byte // Bit i%8 of byte i/8 is set if chunk i is verified.
+}
+
+// NewChunkMap returns a ChunkMap with no verified chunks for a file of size
+// bytes, cut into chunks of chunkSize bytes whose checksums are sums.
+// chunkSize must not exceed size unless size is 0.
+func NewChunkMap(size, chunkSize int64, sums []Digest) (*ChunkMap, error) {
+	if !validChunkSize(size, chunkSize) {
+		return nil, errors.New("whirlpool: invalid chunk map size")
+	}
+	if int64(len(sums)) != (size+chunkSize-1)/chunkSize {
+		return nil, errors.New("whirlpool: chunk count does not match size")
+	}
+	return &ChunkMap{
+		size:      size,
+		chunkSize: chunkSize,
+		sums:      sums,
+		bitmap:    make([]byte, (len(sums)+7)/8),
+	}, nil
+}
+
+// validChunkSize reports whether a file of size bytes can be cut into
+// chunks of chunkSize bytes. A chunk larger than a nonempty file is
+// rejected so that decoded maps cannot make Revalidate allocate a huge
+// buffer.
+func validChunkSize(size, chunkSize int64) bool {
+	return size >= 0 && chunkSize > 0 && (size == 0 || chunkSize <= size)
+}
+
+// Len returns the number of chunks.
+func (m *ChunkMap) Len() int {
+	return len(m.sums)
+}
+
+// Range returns the offset and length of chunk i in the file.
+func (m *ChunkMap) Range(i int) ByteRange {
+	off := int64(i) * m.chunkSize
+	return ByteRange{Offset: off, Length: min(m.chunkSize, m.size-off)}
+}
+
+// Verified reports whether chunk i has been verified.
+func (m *ChunkMap) Verified(i int) bool {
+	return m.bitmap[i/8]&(1<<(i%8)) != 0
+}
+
+// Missing returns the indices of the chunks that have not been verified.
+func (m *ChunkMap) Missing() []int {
+	var missing []int
+	for i := range m.sums {
+		if !m.Verified(i) {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+// Complete reports whether every chunk has been verified.
+func (m *ChunkMap) Complete() bool {
+	for i := range m.sums {
+		if !m.Verified(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify checks the downloaded data of chunk i and marks the chunk verified
+// if it matches. It returns ErrMismatch and clears the mark otherwise.
+func (m *ChunkMap) Verify(i int, data []byte) error {
+	if int64(len(data)) != m.Range(i).Length || Sum512(data) != m.sums[i] {
+		m.bitmap[i/8] &^= 1 << (i % 8)
+		return ErrMismatch
+	}
+	m.bitmap[i/8] |= 1 << (i % 8)
+	return nil
+}
+
+// Revalidate rereads the chunks marked verified from the partial file f and
+// clears the mark of those that no longer match, for example because the
+// file was truncated or modified while the download was suspended. It
+// returns the number of chunks cleared.
+func (m *ChunkMap) Revalidate(f io.ReaderAt) (int, error) {
+	var (
+		cleared int
+		buf     = make([]byte, min(m.chunkSize, m.size))
+	)
+	for i := range m.sums {
+		if !m.Verified(i) {
+			continue
+		}
+		r := m.Range(i)
+		n, err := f.ReadAt(buf[:r.Length], r.Offset)
+		if err != nil && err != io.EOF {
+			return cleared, err
+		}
+		if m.Verify(i, buf[:n]) != nil {
+			cleared++
+		}
+	}
+	return cleared, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (m *ChunkMap) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, len(chunkMapMagic)+16+len(m.sums)*digestBytes+len(m.bitmap))
+	b = append(b, chunkMapMagic...)
+	b = binary.BigEndian.AppendUint64(b, uint64(m.size))
+	b = binary.BigEndian.AppendUint64(b, uint64(m.chunkSize))
+	for _, s := range m.sums {
+		b = append(b, s[:]...)
+	}
+	return append(b, m.bitmap...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (m *ChunkMap) UnmarshalBinary(b []byte) error {
+	if len(b) < len(chunkMapMagic)+16 || string(b[:len(chunkMapMagic)]) != chunkMapMagic {
+		return errors.New("whirlpool: invalid chunk map identifier")
+	}
+	b = b[len(chunkMapMagic):]
+	size, chunkSize := int64(binary.BigEndian.Uint64(b)), int64(binary.BigEndian.Uint64(b[8:]))
+	b = b[16:]
+	if !validChunkSize(size, chunkSize) {
+		return errors.New("whirlpool: invalid chunk map size")
+	}
+	n := (size + chunkSize - 1) / chunkSize
+	if n > int64(len(b))/digestBytes || int64(len(b)) != n*digestBytes+(n+7)/8 {
+		return errors.New("whirlpool: invalid chunk map length")
+	}
+
+	sums := make([]Digest, n)
+	for i := range sums {
+		copy(sums[i][:], b)
+		b = b[digestBytes:]
+	}
+	nm, err := NewChunkMap(size, chunkSize, sums)
+	if err != nil {
+		return err
+	}
+	copy(nm.bitmap, b)
+	if n%8 != 0 && nm.bitmap[len(nm.bitmap)-1]>>(n%8) != 0 {
+		return errors.New("whirlpool: invalid chunk map bitmap")
+	}
+	*m = *nm
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestChunkMap(t *testing.T) {
+	data := []byte(strings.Repeat("0123456789", 100)) // 1000 bytes, 10 chunks of 100.
+	_, sums, err := whirlpool.SumExtents(bytes.NewReader(data), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := whirlpool.NewChunkMap(int64(len(data)), 100, sums)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Download some chunks, one of them corrupted.
+	for _, i := range []int{0, 1, 2, 5, 9} {
+		r := m.Range(i)
+		if err := m.Verify(i, data[r.Offset:r.Offset+r.Length]); err != nil {
+			t.Fatalf("chunk %d: %v", i, err)
+		}
+	}
+	bad := append([]byte{}, data[300:400]...)
+	bad[0] ^= 1
+	if err := m.Verify(3, bad); err != whirlpool.ErrMismatch {
+		t.Fatalf("corrupt chunk: got %v want ErrMismatch", err)
+	}
+	if got := fmt.Sprint(m.Missing()); got != "[3 4 6 7 8]" {
+		t.Fatalf("Missing() = %s", got)
+	}
+
+	// Persist and restore the map, then revalidate against a partial file
+	// that was modified while the download was suspended.
+	state, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r whirlpool.ChunkMap
+	if err := r.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	partial := append([]byte{}, data...)
+	partial[150] ^= 1
+	cleared, err := r.Revalidate(bytes.NewReader(partial))
+	if err != nil || cleared != 1 {
+		t.Fatalf("Revalidate = %d, %v want 1, nil", cleared, err)
+	}
+	if got := fmt.Sprint(r.Missing()); got != "[1 3 4 6 7 8]" {
+		t.Fatalf("Missing() after Revalidate = %s", got)
+	}
+
+	for _, i := range r.Missing() {
+		rg := r.Range(i)
+		if err := r.Verify(i, data[rg.Offset:rg.Offset+rg.Length]); err != nil {
+			t.Fatalf("chunk %d: %v", i, err)
+		}
+	}
+	if !r.Complete() {
+		t.Fatalf("map not complete after verifying all chunks")
+	}
+}
+
+func TestChunkMapShortLastChunk(t *testing.T) {
+	data := []byte(strings.Repeat("x", 250))
+	_, sums, _ := whirlpool.SumExtents(bytes.NewReader(data), 100)
+	m, err := whirlpool.NewChunkMap(250, 100, sums)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := m.Range(2); r != (whirlpool.ByteRange{Offset: 200, Length: 50}) {
+		t.Fatalf("Range(2) = %v", r)
+	}
+	// A truncated partial file fails revalidation of the last chunk.
+	m.Verify(2, data[200:])
+	if n, err := m.Revalidate(bytes.NewReader(data[:220])); n != 1 || err != nil {
+		t.Fatalf("Revalidate of truncated file = %d, %v", n, err)
+	}
+
+	if _, err := whirlpool.NewChunkMap(250, 100, sums[:2]); err == nil {
+		t.Fatalf("chunk count mismatch accepted")
+	}
+}
+
+func TestChunkMapUnmarshalInvalid(t *testing.T) {
+	_, sums, _ := whirlpool.SumExtents(strings.NewReader("abc"), 2)
+	m, _ := whirlpool.NewChunkMap(3, 2, sums)
+	state, _ := m.MarshalBinary()
+
+	for name, b := range map[string][]byte{
+		"empty":     nil,
+		"magic":     append([]byte("xxxx"), state[4:]...),
+		"truncated": state[:len(state)-1],
+		"bitmap":    append(append([]byte{}, state[:len(state)-1]...), 0x04),
+	} {
+		var r whirlpool.ChunkMap
+		if err := r.UnmarshalBinary(b); err == nil {
+			t.Errorf("%s: invalid chunk map accepted", name)
+		}
+	}
+}
+
+func TestChunkMapHugeChunkSize(t *testing.T) {
+	sums := []whirlpool.Digest{whirlpool.Sum512([]byte("a"))}
+	if _, err := whirlpool.NewChunkMap(1, 1<<62, sums); err == nil {
+		t.Fatalf("chunk size larger than the file accepted")
+	}
+
+	// A decoded map must not make Revalidate allocate a chunk of 2^62 bytes.
+	b := []byte("whc\x01")
+	b = binary.BigEndian.AppendUint64(b, 1)
+	b = binary.BigEndian.AppendUint64(b, 1<<62)
+	b = append(b, sums[0][:]...)
+	b = append(b, 1)
+	var m whirlpool.ChunkMap
+	if err := m.UnmarshalBinary(b); err == nil {
+		m.Revalidate(strings.NewReader("a"))
+		t.Fatalf("chunk size larger than the file decoded")
+	}
+
+	// A file of size 0 has no chunks, whatever the chunk size.
+	if _, err := whirlpool.NewChunkMap(0, 1<<62, nil); err != nil {
+		t.Fatalf("empty file: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// CipherBlockSize and CipherKeySize are the block and key sizes of W in
+// bytes.
+const (
+	CipherBlockSize = 64
+	CipherKeySize   = 64
+)
+
+// wCipher is the W block cipher with a fixed key.
+type wCipher struct {
+	key  [8]uint64
+	keys [rounds + 1][64]byte // Round keys, for decryption.
+}
+
+// NewCipher returns the W block cipher underlying whirlpool, keyed with the
+// 64-byte key. W has 64-byte blocks; whirlpool compresses a block m under
+// the chaining value h as W_h(m) xor h xor m.
+func NewCipher(key []byte) (cipher.Block, error) {
+	if len(key) != CipherKeySize {
+		return nil, fmt.Errorf("whirlpool: invalid W key size %d", len(key))
+	}
+	c := new(wCipher)
+	for i := range c.key {
+		c.key[i] = binary.BigEndian.Uint64(key[8*i:])
+	}
+
+	// The key schedule is W itself with the round constants as keys.
+	var k [64]byte
+	copy(k[:], key)
+	c.keys[0] = k
+	bt := byteTables()
+	for r := 1; r <= rounds; r++ {
+		k = bt.round(&k)
+		for j := 0; j < 8; j++ {
+			k[j] ^= byte(rc[r] >> (56 - 8*j))
+		}
+		c.keys[r] = k
+	}
+	return c, nil
+}
+
+func (c *wCipher) BlockSize() int {
+	return CipherBlockSize
+}
+
+func (c *wCipher) Encrypt(dst, src []byte) {
+	if len(src) < CipherBlockSize || len(dst) < CipherBlockSize {
+		panic("whirlpool: input not full block")
+	}
+	// The compression function is W_key(m) xor key xor m.
+	var (
+		h = c.key
+		m [wblockBytes]byte
+	)
+	copy(m[:], src)
+	whirlpoolTables.compress(&h, &m)
+	for i := range h {
+		x := h[i] ^ c.key[i] ^ binary.BigEndian.Uint64(m[8*i:])
+		binary.BigEndian.PutUint64(dst[8*i:], x)
+	}
+}
+
+func (c *wCipher) Decrypt(dst, src []byte) {
+	if len(src) < CipherBlockSize || len(dst) < CipherBlockSize {
+		panic("whirlpool: input not full block")
+	}
+	bt := byteTables()
+	var s [64]byte
+	copy(s[:], src)
+	for r := rounds; r >= 1; r-- {
+		for i := range s {
+			s[i] ^= c.keys[r][i]
+		}
+		s = bt.invRound(&s)
+	}
+	for i := range s {
+		dst[i] = s[i] ^ c.keys[0][i]
+	}
+}
+
+// cipherTables hold what the bytewise rounds of W need.
+type cipherTables struct {
+	s, sInv [256]byte
+	c, cInv [8]byte // First rows of the diffusion matrix and its inverse.
+}
+
+// byteTables returns the tables for bytewise W rounds, computed on first use.
+var byteTables = sync.OnceValue(func() *cipherTables {
+	t := &cipherTables{c: [8]byte{1, 1, 4, 1, 8, 5, 2, 9}}
+	t.s = *sbox()
+	for x, y := range t.s {
+		t.sInv[y] = byte(x)
+	}
+	t.cInv = invCirculant(t.c)
+	return t
+})
+
+// round applies the nonlinear layer, the cyclical permutation and the
+// linear diffusion layer to the state a, rows being consecutive bytes.
+func (t *cipherTables) round(a *[64]byte) [64]byte {
+	var b, out [64]byte
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			// Column j is shifted down by j rows.
+			b[8*i+j] = t.s[a[8*((i-j+8)%8)+j]]
+		}
+	}
+	mixRows(&out, &b, &t.c)
+	return out
+}
+
+// invRound inverts round.
+func (t *cipherTables) invRound(a *[64]byte) [64]byte {
+	var b, out [64]byte
+	mixRows(&b, a, &t.cInv)
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			out[8*((i-j+8)%8)+j] = t.sInv[b[8*i+j]]
+		}
+	}
+	return out
+}
+
+// mixRows multiplies every row of a by the circulant matrix with first row c.
+func mixRows(dst, a *[64]byte, c *[8]byte) {
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			var v byte
+			for k := 0; k < 8; k++ {
+				v ^= gfMul(a[8*i+k], c[(j-k+8)%8])
+			}
+			dst[8*i+j] = v
+		}
+	}
+}
+
+// invCirculant returns the first row of the inverse of the circulant matrix
+// with first row c, by Gauss-Jordan elimination over GF(2^8).
+func invCirculant(c [8]byte) [8]byte {
+	var m [8][16]byte
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			m[i][j] = c[(j-i+8)%8]
+		}
+		m[i][8+i] = 1
+	}
+	for col := 0; col < 8; col++ {
+		p := col
+		for m[p][col] == 0 {
+			p++
+		}
+		m[col], m[p] = m[p], m[col]
+		inv := gfInv(m[col][col])
+		for j := range m[col] {
+			m[col][j] = gfMul(m[col][j], inv)
+		}
+		for i := 0; i < 8; i++ {
+			if f := m[i][col]; i != col && f != 0 {
+				for j := range m[i] {
+					m[i][j] ^= gfMul(f, m[col][j])
+				}
+			}
+		}
+	}
+	var row [8]byte
+	copy(row[:], m[0][8:])
+	return row
+}
+
+// gfInv returns the multiplicative inverse of a nonzero a, a^254.
+func gfInv(a byte) byte {
+	r := byte(1)
+	for i := 0; i < 254; i++ {
+		r = gfMul(r, a)
+	}
+	return r
+}
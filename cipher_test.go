@@ -0,0 +1,75 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestCipher(t *testing.T) {
+	for _, tt := range []struct {
+		key, pt []byte
+		ct      string
+	}{
+		{make([]byte, 64), make([]byte, 64),
+			"fb49073c4d7e581a7ac059cb42c15c0895e086d02e819c91bffcff66a605db91f0b621801b7f899df4e4e0d6653151232ecb2cedb7df1842c4c36538256db099"},
+		{seq(0, 64), seq(64, 64),
+			"4495b76de56e4efc6aa4c5e7e7ad7193a865ecdc73f91844e62843244c0dd6f4767ba213c36e19aaf05bebce8ae0441a0ec768f2de36fec06212d3321e34963a"},
+	} {
+		c, err := whirlpool.NewCipher(tt.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.BlockSize() != 64 {
+			t.Fatalf("BlockSize() = %d", c.BlockSize())
+		}
+		ct := make([]byte, 64)
+		c.Encrypt(ct, tt.pt)
+		if hex.EncodeToString(ct) != tt.ct {
+			t.Fatalf("Encrypt = %x want %s", ct, tt.ct)
+		}
+		pt := make([]byte, 64)
+		c.Decrypt(pt, ct)
+		if !bytes.Equal(pt, tt.pt) {
+			t.Fatalf("Decrypt = %x want %x", pt, tt.pt)
+		}
+	}
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	key, block := make([]byte, 64), make([]byte, 64)
+	for i := 0; i < 20; i++ {
+		rnd.Read(key)
+		rnd.Read(block)
+		c, _ := whirlpool.NewCipher(key)
+		buf := append([]byte{}, block...)
+		c.Encrypt(buf, buf) // In place.
+		if bytes.Equal(buf, block) {
+			t.Fatalf("Encrypt is the identity")
+		}
+		c.Decrypt(buf, buf)
+		if !bytes.Equal(buf, block) {
+			t.Fatalf("Decrypt(Encrypt(%x)) = %x", block, buf)
+		}
+	}
+
+	if _, err := whirlpool.NewCipher(make([]byte, 32)); err == nil {
+		t.Fatalf("short key accepted")
+	}
+}
+
+func seq(start, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(start + i)
+	}
+	return b
+}
@@ -0,0 +1,63 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"errors"
+	"hash"
+	"io"
+)
+
+var errClosed = errors.New("whirlpool: DigestCloser already closed")
+
+// DigestCloser is an io.WriteCloser that hashes everything written to it
+// and passes the data on to an underlying writer. The digest is finalized
+// and published when the DigestCloser is closed.
+type DigestCloser struct {
+	w      io.Writer
+	h      hash.Hash
+	done   func(sum []byte)
+	closed bool
+}
+
+// NewDigestCloser returns a DigestCloser that writes through to w, which
+// may be nil if the data only needs to be hashed. On Close the underlying
+// writer is closed first, if it implements io.Closer, and then done is
+// called with the whirlpool checksum of all the data written.
+func NewDigestCloser(w io.Writer, done func(sum []byte)) *DigestCloser {
+	return &DigestCloser{w: w, h: New(), done: done}
+}
+
+func (d *DigestCloser) Write(p []byte) (int, error) {
+	if d.closed {
+		return 0, errClosed
+	}
+	if d.w != nil {
+		n, err := d.w.Write(p)
+		// Only hash what actually made it downstream.
+		d.h.Write(p[:n])
+		return n, err
+	}
+	return d.h.Write(p)
+}
+
+// Close closes the underlying writer and publishes the digest. The digest
+// is published even if closing the underlying writer fails, in which case
+// that error is returned.
+func (d *DigestCloser) Close() error {
+	if d.closed {
+		return errClosed
+	}
+	d.closed = true
+
+	var err error
+	if c, ok := d.w.(io.Closer); ok {
+		err = c.Close()
+	}
+	if d.done != nil {
+		d.done(d.h.Sum(nil))
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestDigestCloser(t *testing.T) {
+	for _, g := range golden {
+		var (
+			dst closeRecorder
+			sum []byte
+		)
+		d := whirlpool.NewDigestCloser(&dst, func(s []byte) {
+			if !dst.closed {
+				t.Errorf("digest published before the underlying writer was closed")
+			}
+			sum = s
+		})
+		io.WriteString(d, g.in)
+		if err := d.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		if dst.String() != g.in {
+			t.Fatalf("underlying writer got %q want %q", dst.String(), g.in)
+		}
+		if s := fmt.Sprintf("%X", sum); s != g.out {
+			t.Fatalf("DigestCloser(%s) = %s want %s", g.in, s, g.out)
+		}
+		if _, err := io.WriteString(d, "more"); err == nil {
+			t.Fatalf("Write after Close succeeded")
+		}
+		if err := d.Close(); err == nil {
+			t.Fatalf("second Close succeeded")
+		}
+	}
+}
+
+func TestDigestCloserNilWriter(t *testing.T) {
+	g := golden[3]
+	var sum []byte
+	d := whirlpool.NewDigestCloser(nil, func(s []byte) { sum = s })
+	io.WriteString(d, g.in)
+	d.Close()
+	if s := fmt.Sprintf("%X", sum); s != g.out {
+		t.Fatalf("DigestCloser(%s) = %s want %s", g.in, s, g.out)
+	}
+}
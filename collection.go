@@ -0,0 +1,58 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import "sort"
+
+// HashStrings returns the whirlpool checksum of the string slice s. The
+// encoding is order-sensitive and identical to HashStruct(s): a list tag and
+// the element count, followed by every string as a string tag, its length
+// and its bytes. Lengths and counts are 64-bit big-endian integers, so
+// []string{"ab"} and []string{"a", "b"} hash differently.
+func HashStrings(s []string) Digest {
+	var w whirlpool
+	e := encoder{w: &w}
+	if s == nil {
+		e.tag(tagNil)
+	} else {
+		e.tagUint64(tagList, uint64(len(s)))
+		for _, v := range s {
+			e.string(v)
+		}
+	}
+	return w.checkSum()
+}
+
+// HashMap returns the whirlpool checksum of the map m. The encoding is
+// independent of map iteration order and identical to HashStruct(m): a map
+// tag and the entry count, followed by every key and value encoded as in
+// HashStrings. Entries are ordered by their encoded keys, that is by key
+// length first and then bytewise.
+func HashMap(m map[string]string) Digest {
+	var w whirlpool
+	e := encoder{w: &w}
+	if m == nil {
+		e.tag(tagNil)
+		return w.checkSum()
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) < len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+
+	e.tagUint64(tagMap, uint64(len(keys)))
+	for _, k := range keys {
+		e.string(k)
+		e.string(m[k])
+	}
+	return w.checkSum()
+}
@@ -0,0 +1,49 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestHashStrings(t *testing.T) {
+	for _, s := range [][]string{nil, {}, {""}, {"a"}, {"ab"}, {"a", "b"}, {"b", "a"}} {
+		if got, want := whirlpool.HashStrings(s), mustHashStruct(t, s); got != want {
+			t.Fatalf("HashStrings(%q) = %s want HashStruct encoding %s", s, got, want)
+		}
+	}
+	if whirlpool.HashStrings([]string{"a", "b"}) == whirlpool.HashStrings([]string{"b", "a"}) {
+		t.Fatalf("HashStrings is not order-sensitive")
+	}
+	if whirlpool.HashStrings([]string{"ab"}) == whirlpool.HashStrings([]string{"a", "b"}) {
+		t.Fatalf("HashStrings is ambiguous")
+	}
+}
+
+func TestHashMap(t *testing.T) {
+	maps := []map[string]string{
+		nil,
+		{},
+		{"": ""},
+		{"a": "b"},
+		{"zz": "1", "a": "2", "b": "3", "aaa": "4", "ab": "5"},
+	}
+	for _, m := range maps {
+		if got, want := whirlpool.HashMap(m), mustHashStruct(t, m); got != want {
+			t.Fatalf("HashMap(%q) = %s want HashStruct encoding %s", m, got, want)
+		}
+	}
+
+	a := map[string]string{"x": "1", "y": "2"}
+	b := map[string]string{"y": "2", "x": "1"}
+	if whirlpool.HashMap(a) != whirlpool.HashMap(b) {
+		t.Fatalf("HashMap depends on insertion order")
+	}
+	if whirlpool.HashMap(a) == whirlpool.HashMap(map[string]string{"x": "2", "y": "1"}) {
+		t.Fatalf("HashMap ignores key/value pairing")
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Mismatch locates the first difference between two inputs.
+type Mismatch struct {
+	// Offset is the first byte at which the inputs differ, or the length of
+	// the shorter input if it is a prefix of the other.
+	Offset int64
+	// Block is the index of the block holding Offset.
+	Block int64
+	// SumA and SumB are the checksums of that block in either input.
+	SumA, SumB Digest
+}
+
+func (m *Mismatch) String() string {
+	return fmt.Sprintf("first difference at offset %d (0x%x) in block %d: %s != %s",
+		m.Offset, m.Offset, m.Block, m.SumA, m.SumB)
+}
+
+// Compare locates the first difference between a and b, of sizeA and sizeB
+// bytes, using only whirlpool checksums of byte ranges: blocks of blockSize
+// bytes are compared in order and the first differing block is then
+// bisected down to a single byte. Compare returns nil if the inputs are
+// identical.
+func Compare(a io.ReaderAt, sizeA int64, b io.ReaderAt, sizeB int64, blockSize int64) (*Mismatch, error) {
+	if blockSize <= 0 {
+		return nil, errors.New("whirlpool: block size must be positive")
+	}
+
+	n := min(sizeA, sizeB)
+	for off := int64(0); off < n; off += blockSize {
+		end := min(off+blockSize, n)
+		sa, err := sumRange(a, off, end-off)
+		if err != nil {
+			return nil, err
+		}
+		sb, err := sumRange(b, off, end-off)
+		if err != nil {
+			return nil, err
+		}
+		if sa == sb {
+			continue
+		}
+
+		// The difference is in [lo, hi); bisect until it is a single byte.
+		lo, hi := off, end
+		for hi-lo > 1 {
+			mid := lo + (hi-lo)/2
+			if sa, err = sumRange(a, lo, mid-lo); err != nil {
+				return nil, err
+			}
+			if sb, err = sumRange(b, lo, mid-lo); err != nil {
+				return nil, err
+			}
+			if sa != sb {
+				hi = mid
+			} else {
+				lo = mid
+			}
+		}
+		return mismatchAt(a, sizeA, b, sizeB, blockSize, lo)
+	}
+	if sizeA != sizeB {
+		return mismatchAt(a, sizeA, b, sizeB, blockSize, n)
+	}
+	return nil, nil
+}
+
+// CompareFiles is Compare for the files named a and b.
+func CompareFiles(a, b string, blockSize int64) (*Mismatch, error) {
+	fa, sizeA, err := openSized(a)
+	if err != nil {
+		return nil, err
+	}
+	defer fa.Close()
+	fb, sizeB, err := openSized(b)
+	if err != nil {
+		return nil, err
+	}
+	defer fb.Close()
+	return Compare(fa, sizeA, fb, sizeB, blockSize)
+}
+
+func openSized(name string) (*os.File, int64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, fi.Size(), nil
+}
+
+// mismatchAt returns the Mismatch for a first difference at off.
+func mismatchAt(a io.ReaderAt, sizeA int64, b io.ReaderAt, sizeB int64, blockSize, off int64) (*Mismatch, error) {
+	m := &Mismatch{Offset: off, Block: off / blockSize}
+	start := m.Block * blockSize
+	var err error
+	if m.SumA, err = sumRange(a, start, min(start+blockSize, sizeA)-start); err != nil {
+		return nil, err
+	}
+	if m.SumB, err = sumRange(b, start, min(start+blockSize, sizeB)-start); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// sumRange returns the checksum of the n bytes of r at off.
+func sumRange(r io.ReaderAt, off, n int64) (Digest, error) {
+	var w whirlpool
+	if _, err := io.Copy(&w, io.NewSectionReader(r, off, n)); err != nil {
+		return Digest{}, err
+	}
+	return w.checkSum(), nil
+}
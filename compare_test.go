@@ -0,0 +1,64 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestCompare(t *testing.T) {
+	data := []byte(strings.Repeat("0123456789", 1000))
+
+	for _, off := range []int{0, 1, 999, 1000, 1023, 4097, len(data) - 1} {
+		bad := append([]byte{}, data...)
+		bad[off] ^= 0x20
+		m, err := whirlpool.Compare(bytes.NewReader(data), int64(len(data)), bytes.NewReader(bad), int64(len(bad)), 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m == nil || m.Offset != int64(off) || m.Block != int64(off/1024) {
+			t.Fatalf("corrupt byte %d: got %v", off, m)
+		}
+		start := off / 1024 * 1024
+		end := min(start+1024, len(data))
+		if m.SumA != whirlpool.Sum512(data[start:end]) || m.SumB != whirlpool.Sum512(bad[start:end]) {
+			t.Fatalf("corrupt byte %d: wrong block digests", off)
+		}
+	}
+
+	// Truncation is reported at the length of the shorter input.
+	m, err := whirlpool.Compare(bytes.NewReader(data), int64(len(data)), bytes.NewReader(data[:3000]), 3000, 1024)
+	if err != nil || m == nil || m.Offset != 3000 || m.Block != 2 {
+		t.Fatalf("truncated input: got %v, %v", m, err)
+	}
+
+	if m, err := whirlpool.Compare(bytes.NewReader(data), int64(len(data)), bytes.NewReader(data), int64(len(data)), 1024); m != nil || err != nil {
+		t.Fatalf("identical inputs: got %v, %v", m, err)
+	}
+}
+
+func TestCompareFiles(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	os.WriteFile(a, []byte("hello, world"), 0644)
+	os.WriteFile(b, []byte("hello, World"), 0644)
+
+	m, err := whirlpool.CompareFiles(a, b, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || m.Offset != 7 || m.Block != 1 {
+		t.Fatalf("got %v want difference at offset 7", m)
+	}
+	if !strings.HasPrefix(m.String(), "first difference at offset 7 (0x7) in block 1: ") {
+		t.Fatalf("String() = %s", m)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// CopyVerified copies src to the file dst while hashing it, and replaces dst
+// only if the data matches the whirlpool checksum sum. The data is written
+// to a temporary file in the directory of dst, which is renamed over dst on
+// success and removed otherwise, so dst is never left partially written. An
+// existing dst keeps its permissions.
+//
+// CopyVerified returns the number of bytes copied and ErrMismatch if the
+// data does not match sum.
+func CopyVerified(dst string, src io.Reader, sum []byte) (n int64, err error) {
+	perm := fs.FileMode(0644)
+	if fi, err := os.Stat(dst); err == nil {
+		perm = fi.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	w := New()
+	n, err = io.Copy(io.MultiWriter(tmp, w), src)
+	if err != nil {
+		return n, err
+	}
+	if !bytes.Equal(w.Sum(nil), sum) {
+		return n, ErrMismatch
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return n, err
+	}
+	if err = tmp.Sync(); err != nil {
+		return n, err
+	}
+	if err = tmp.Close(); err != nil {
+		return n, err
+	}
+	return n, os.Rename(tmp.Name(), dst)
+}
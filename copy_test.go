@@ -0,0 +1,46 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestCopyVerified(t *testing.T) {
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "mirror.bin")
+	if err := os.WriteFile(dst, []byte("corrupt"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A mismatching copy leaves dst alone.
+	n, err := whirlpool.CopyVerified(dst, strings.NewReader("abd"), sum([]byte("abc")))
+	if err != whirlpool.ErrMismatch || n != 3 {
+		t.Fatalf("CopyVerified = %d, %v want 3, ErrMismatch", n, err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "corrupt" {
+		t.Fatalf("dst changed to %q after a failed copy", data)
+	}
+
+	if _, err := whirlpool.CopyVerified(dst, strings.NewReader("abc"), sum([]byte("abc"))); err != nil {
+		t.Fatalf("CopyVerified: %v", err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "abc" {
+		t.Fatalf("dst = %q want %q", data, "abc")
+	}
+	if fi, _ := os.Stat(dst); fi.Mode().Perm() != 0600 {
+		t.Fatalf("dst mode = %v want 0600", fi.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("temporary files left behind: %v", entries)
+	}
+}
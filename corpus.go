@@ -0,0 +1,100 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// Corpus is a set of inputs and their expected whirlpool checksums. It
+// encodes to JSON, so a corpus built once from known inputs can be shipped
+// alongside them and checked later with Validate, for example to
+// self-test a vendored copy of this package at startup.
+type Corpus struct {
+	Entries []CorpusEntry `json:"entries"`
+}
+
+// CorpusEntry is the expected checksum of one file of a Corpus.
+type CorpusEntry struct {
+	Path string `json:"path"`      // Slash-separated path in the file system.
+	Size int64  `json:"size"`      // Size in bytes.
+	Sum  string `json:"whirlpool"` // Lowercase hex checksum.
+}
+
+// BuildCorpus hashes every regular file of fsys, in lexical path order, and
+// returns the resulting Corpus.
+func BuildCorpus(fsys fs.FS) (*Corpus, error) {
+	c := new(Corpus)
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sum, err := sumFS(fsys, path)
+		if err != nil {
+			return err
+		}
+		c.Entries = append(c.Entries, CorpusEntry{
+			Path: path,
+			Size: info.Size(),
+			Sum:  hex.EncodeToString(sum),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate checks every entry of c against the files of fsys. It returns nil
+// if all of them match, or an error wrapping ErrMismatch or the I/O error of
+// every entry that does not; a file matches if both its size and checksum
+// do. Entries with a negative size or a checksum that is not the hex
+// encoding of a whirlpool checksum are reported as malformed without
+// reading their files.
+func (c *Corpus) Validate(fsys fs.FS) error {
+	var errs []error
+	for _, e := range c.Entries {
+		want, err := ParseDigest(e.Sum)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("whirlpool: %s: malformed checksum %q", e.Path, e.Sum))
+			continue
+		}
+		if e.Size < 0 {
+			errs = append(errs, fmt.Errorf("whirlpool: %s: malformed size %d", e.Path, e.Size))
+			continue
+		}
+		sum, n, err := sumFSSize(fsys, e.Path)
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case n != e.Size:
+			errs = append(errs, fmt.Errorf("%s: size %d, want %d: %w", e.Path, n, e.Size, ErrMismatch))
+		case sum != want:
+			errs = append(errs, fmt.Errorf("%s: %w", e.Path, ErrMismatch))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sumFSSize is like sumFS but also returns the number of bytes hashed.
+func sumFSSize(fsys fs.FS, name string) (Digest, int64, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return Digest{}, 0, err
+	}
+	defer f.Close()
+	return SumReader(f)
+}
@@ -0,0 +1,90 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestCorpus(t *testing.T) {
+	fsys := fstest.MapFS{
+		"b.txt":     {Data: []byte("abc")},
+		"a/one.txt": {Data: []byte("a")},
+		"empty":     {Data: nil},
+	}
+	c, err := whirlpool.BuildCorpus(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []whirlpool.CorpusEntry{
+		{"a/one.txt", 1, strings.ToLower(golden[1].out)},
+		{"b.txt", 3, strings.ToLower(golden[3].out)},
+		{"empty", 0, strings.ToLower(golden[0].out)},
+	}
+	if len(c.Entries) != len(want) {
+		t.Fatalf("got %d entries want %d", len(c.Entries), len(want))
+	}
+	for i := range want {
+		if c.Entries[i] != want[i] {
+			t.Fatalf("entry %d = %+v want %+v", i, c.Entries[i], want[i])
+		}
+	}
+
+	// Round-trip through JSON and validate.
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded whirlpool.Corpus
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.Validate(fsys); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	fsys["b.txt"] = &fstest.MapFile{Data: []byte("abd")}
+	delete(fsys, "empty")
+	err = loaded.Validate(fsys)
+	if !errors.Is(err, whirlpool.ErrMismatch) || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Validate of modified files = %v", err)
+	}
+}
+
+func TestCorpusMalformed(t *testing.T) {
+	fsys := fstest.MapFS{"a": {Data: []byte("a")}}
+	sum := strings.ToLower(golden[1].out)
+	for _, bad := range []string{"", sum[:len(sum)-2], sum + "00", "zz" + sum[2:]} {
+		c := whirlpool.Corpus{Entries: []whirlpool.CorpusEntry{
+			{"a", 1, sum},
+			{"dir/entry", 1, bad},
+		}}
+		err := c.Validate(fsys)
+		if err == nil || errors.Is(err, whirlpool.ErrMismatch) || !strings.Contains(err.Error(), "dir/entry") {
+			t.Errorf("Validate with checksum %q = %v", bad, err)
+		}
+	}
+
+	c := whirlpool.Corpus{Entries: []whirlpool.CorpusEntry{{"dir/entry", -1, sum}}}
+	if err := c.Validate(fsys); err == nil || errors.Is(err, whirlpool.ErrMismatch) || !strings.Contains(err.Error(), "dir/entry") {
+		t.Errorf("Validate with a negative size = %v", err)
+	}
+}
+
+func TestCorpusSizeMismatch(t *testing.T) {
+	fsys := fstest.MapFS{"a": {Data: []byte("a")}}
+	c := whirlpool.Corpus{Entries: []whirlpool.CorpusEntry{{"a", 2, strings.ToLower(golden[1].out)}}}
+	if err := c.Validate(fsys); !errors.Is(err, whirlpool.ErrMismatch) {
+		t.Fatalf("Validate with the wrong size = %v want ErrMismatch", err)
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !unix
+
+package whirlpool
+
+import "io/fs"
+
+// deviceOf reports that devices are unknown on this platform.
+func deviceOf(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
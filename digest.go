@@ -0,0 +1,160 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Digest is a whirlpool checksum. Its text form is lowercase hex. Since
+// Digest is a fmt.Stringer, %x and %X encode the String result again; use
+// d[:] to format the raw bytes, for example as uppercase hex.
+type Digest [Size]byte
+
+// EmptyDigest is the whirlpool checksum of the empty message.
+var EmptyDigest = emptyDigest
+
+// emptyDigest is returned by the hashes of New when they had no input, without
+// hashing anything. It is unexported so that callers cannot change it.
+var emptyDigest = Digest{
+	0x19, 0xfa, 0x61, 0xd7, 0x55, 0x22, 0xa4, 0x66,
+	0x9b, 0x44, 0xe3, 0x9c, 0x1d, 0x2e, 0x17, 0x26,
+	0xc5, 0x30, 0x23, 0x21, 0x30, 0xd4, 0x07, 0xf8,
+	0x9a, 0xfe, 0xe0, 0x96, 0x49, 0x97, 0xf7, 0xa7,
+	0x3e, 0x83, 0xbe, 0x69, 0x8b, 0x28, 0x8f, 0xeb,
+	0xcf, 0x88, 0xe3, 0xe0, 0x3c, 0x4f, 0x07, 0x57,
+	0xea, 0x89, 0x64, 0xe5, 0x9b, 0x63, 0xd9, 0x37,
+	0x08, 0xb1, 0x38, 0xcc, 0x42, 0xa6, 0x6e, 0xb3,
+}
+
+// ParseDigest parses a digest in upper- or lowercase hex.
+func ParseDigest(s string) (Digest, error) {
+	var d Digest
+	err := d.UnmarshalText([]byte(s))
+	return d, err
+}
+
+// String returns d in lowercase hex.
+func (d Digest) String() string {
+	return d.hex(Size)
+}
+
+// hex returns the first n bytes of d in lowercase hex.
+func (d *Digest) hex(n int) string {
+	var buf [2 * Size]byte
+	hex.Encode(buf[:], d[:n])
+	return string(buf[:2*n])
+}
+
+// IsEmpty reports whether d is the checksum of the empty message, that is of
+// empty input such as a zero-length file.
+func (d Digest) IsEmpty() bool {
+	return d == emptyDigest
+}
+
+// AppendHex appends d in lowercase hex to dst and returns the extended
+// buffer. It does not allocate if dst has room for 2*Size bytes.
+func (d Digest) AppendHex(dst []byte) []byte {
+	return hex.AppendEncode(dst, d[:])
+}
+
+// AppendBase64 appends d in standard padded base64 to dst and returns the
+// extended buffer. It does not allocate if dst has room for the 88 encoded
+// bytes.
+func (d Digest) AppendBase64(dst []byte) []byte {
+	return base64.StdEncoding.AppendEncode(dst, d[:])
+}
+
+// base32Encoding is RFC 4648 base32 in lowercase without padding, which
+// suits case-insensitive file systems and host names.
+var base32Encoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// Base32 returns d in unpadded lowercase base32, 103 characters instead of
+// the 128 of hex, for file names and other case-insensitive contexts.
+func (d Digest) Base32() string {
+	return base32Encoding.EncodeToString(d[:])
+}
+
+// ParseBase32 parses a digest in unpadded base32 in either case, as
+// returned by Base32.
+func ParseBase32(s string) (Digest, error) {
+	var d Digest
+	if base32Encoding.DecodedLen(len(s)) != Size {
+		return d, errors.New("whirlpool: invalid digest length")
+	}
+	s = strings.ToLower(s)
+	if _, err := base32Encoding.Decode(d[:], []byte(s)); err != nil {
+		return Digest{}, errors.New("whirlpool: invalid digest")
+	}
+	// encoding/base32 has no Strict mode, so catch nonzero trailing bits by
+	// encoding the result again.
+	if d.Base32() != s {
+		return Digest{}, errors.New("whirlpool: invalid digest")
+	}
+	return d, nil
+}
+
+// Base64URL returns d in unpadded URL-safe base64 (RFC 4648 section 5), 86
+// characters, for URLs and query parameters.
+func (d Digest) Base64URL() string {
+	return base64.RawURLEncoding.EncodeToString(d[:])
+}
+
+// ParseBase64URL parses a digest in unpadded URL-safe base64, as returned by
+// Base64URL.
+func ParseBase64URL(s string) (Digest, error) {
+	var d Digest
+	if base64.RawURLEncoding.DecodedLen(len(s)) != Size {
+		return d, errors.New("whirlpool: invalid digest length")
+	}
+	if _, err := base64.RawURLEncoding.Strict().Decode(d[:], []byte(s)); err != nil {
+		return Digest{}, errors.New("whirlpool: invalid digest")
+	}
+	return d, nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Digest) MarshalText() ([]byte, error) {
+	return d.AppendHex(make([]byte, 0, 2*Size)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts upper- and
+// lowercase hex, and leaves d unchanged on error.
+func (d *Digest) UnmarshalText(text []byte) error {
+	if len(text) != 2*Size {
+		return errors.New("whirlpool: invalid digest length")
+	}
+	var v Digest
+	if _, err := hex.Decode(v[:], text); err != nil {
+		return errors.New("whirlpool: invalid digest")
+	}
+	*d = v
+	return nil
+}
+
+// Set implements flag.Value, so that a Digest can be a command-line flag:
+//
+//	var want whirlpool.Digest
+//	flag.Var(&want, "sum", "expected whirlpool checksum")
+func (d *Digest) Set(s string) error {
+	return d.UnmarshalText([]byte(s))
+}
+
+// Equal reports whether d and o are equal, in constant time so that it can
+// compare digests used as authenticators.
+func (d Digest) Equal(o Digest) bool {
+	return subtle.ConstantTimeCompare(d[:], o[:]) == 1
+}
+
+// EqualBytes reports whether b holds the digest d, in constant time. It is
+// false if b has the wrong length.
+func (d Digest) EqualBytes(b []byte) bool {
+	return subtle.ConstantTimeCompare(d[:], b) == 1
+}
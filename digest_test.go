@@ -0,0 +1,178 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestDigestEncoding(t *testing.T) {
+	for _, g := range golden {
+		d := whirlpool.Sum512([]byte(g.in))
+		if s := d.String(); s != strings.ToLower(g.out) {
+			t.Fatalf("String() = %s want %s", s, strings.ToLower(g.out))
+		}
+		if s := string(d.AppendHex([]byte("x="))); s != "x="+strings.ToLower(g.out) {
+			t.Fatalf("AppendHex = %s", s)
+		}
+		want := "x=" + base64.StdEncoding.EncodeToString(d[:])
+		if s := string(d.AppendBase64([]byte("x="))); s != want {
+			t.Fatalf("AppendBase64 = %s want %s", s, want)
+		}
+	}
+
+	var d whirlpool.Digest
+	buf := make([]byte, 0, 2*whirlpool.Size)
+	if n := testing.AllocsPerRun(100, func() {
+		buf = d.AppendHex(buf[:0])
+		buf = d.AppendBase64(buf[:0])
+	}); n != 0 {
+		t.Errorf("AppendHex and AppendBase64: %v allocs, want 0", n)
+	}
+}
+
+func TestDigestText(t *testing.T) {
+	d := whirlpool.Sum512([]byte("abc"))
+	text, err := d.MarshalText()
+	if err != nil || string(text) != strings.ToLower(golden[3].out) {
+		t.Fatalf("MarshalText = %s, %v", text, err)
+	}
+
+	for _, s := range []string{golden[3].out, strings.ToLower(golden[3].out)} {
+		p, err := whirlpool.ParseDigest(s)
+		if err != nil || !p.Equal(d) || p != d {
+			t.Fatalf("ParseDigest(%s) = %s, %v", s, p, err)
+		}
+	}
+	for _, s := range []string{"", golden[3].out[:126], golden[3].out + "00", "zz" + golden[3].out[2:]} {
+		if _, err := whirlpool.ParseDigest(s); err == nil {
+			t.Fatalf("ParseDigest(%q) accepted", s)
+		}
+	}
+
+	// Digests round-trip through JSON as hex strings.
+	b, err := json.Marshal(map[string]whirlpool.Digest{"sum": d})
+	if err != nil || string(b) != `{"sum":"`+strings.ToLower(golden[3].out)+`"}` {
+		t.Fatalf("json.Marshal = %s, %v", b, err)
+	}
+	var m map[string]whirlpool.Digest
+	if err := json.Unmarshal(b, &m); err != nil || m["sum"] != d {
+		t.Fatalf("json.Unmarshal = %v, %v", m, err)
+	}
+}
+
+func TestDigestEqual(t *testing.T) {
+	a, b := whirlpool.Sum512([]byte("a")), whirlpool.Sum512([]byte("b"))
+	if !a.Equal(a) || a.Equal(b) {
+		t.Fatalf("Equal is wrong")
+	}
+	if !a.EqualBytes(sum([]byte("a"))) || a.EqualBytes(sum([]byte("b"))) || a.EqualBytes(a[:32]) {
+		t.Fatalf("EqualBytes is wrong")
+	}
+}
+
+func TestEmptyDigest(t *testing.T) {
+	if s := whirlpool.EmptyDigest.String(); s != strings.ToLower(golden[0].out) {
+		t.Fatalf("EmptyDigest = %s want %s", s, strings.ToLower(golden[0].out))
+	}
+
+	// NewWith sets the tables explicitly, which skips the fast path.
+	w, _ := whirlpool.NewWith()
+	if d := whirlpool.Digest(w.Sum(nil)); d != whirlpool.EmptyDigest {
+		t.Fatalf("hashing no input gives %s", d)
+	}
+	if d := whirlpool.Digest(whirlpool.New().Sum(nil)); !d.IsEmpty() {
+		t.Fatalf("New().Sum(nil) = %s", d)
+	}
+	if whirlpool.Sum512(nil) != whirlpool.EmptyDigest || whirlpool.Sum512([]byte{0}).IsEmpty() {
+		t.Fatalf("Sum512 disagrees with EmptyDigest")
+	}
+
+	// A chaining value set without input is not the empty message.
+	r := whirlpool.NewRaw()
+	r.SetState([8]uint64{1})
+	if whirlpool.Digest(r.Sum(nil)).IsEmpty() {
+		t.Fatalf("SetState ignored by the fast path")
+	}
+}
+
+func TestDigestFlag(t *testing.T) {
+	var d whirlpool.Digest
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&d, "sum", "checksum")
+	if err := fs.Parse([]string{"-sum", golden[3].out}); err != nil {
+		t.Fatal(err)
+	}
+	if d != whirlpool.Sum512([]byte(golden[3].in)) {
+		t.Fatalf("flag parsed as %s", d)
+	}
+	if err := fs.Parse([]string{"-sum", "zz" + golden[3].out[2:]}); err == nil {
+		t.Fatalf("invalid flag value accepted")
+	}
+	if d != whirlpool.Sum512([]byte(golden[3].in)) {
+		t.Fatalf("invalid flag value changed the digest to %s", d)
+	}
+}
+
+func TestDigestBase32Base64URL(t *testing.T) {
+	for _, g := range golden {
+		d := whirlpool.Sum512([]byte(g.in))
+
+		b32 := d.Base32()
+		if len(b32) != 103 || b32 != strings.ToLower(b32) || strings.Contains(b32, "=") {
+			t.Fatalf("Base32() = %q", b32)
+		}
+		for _, s := range []string{b32, strings.ToUpper(b32)} {
+			if p, err := whirlpool.ParseBase32(s); err != nil || p != d {
+				t.Fatalf("ParseBase32(%s) = %s, %v", s, p, err)
+			}
+		}
+
+		b64 := d.Base64URL()
+		if len(b64) != 86 || strings.ContainsAny(b64, "+/=") {
+			t.Fatalf("Base64URL() = %q", b64)
+		}
+		if p, err := whirlpool.ParseBase64URL(b64); err != nil || p != d {
+			t.Fatalf("ParseBase64URL(%s) = %s, %v", b64, p, err)
+		}
+	}
+
+	d := whirlpool.Sum512(nil)
+	// The last base32 character carries 2 bits of the digest and 3 zero bits.
+	b32 := d.Base32()
+	last := strings.IndexByte("abcdefghijklmnopqrstuvwxyz234567", b32[len(b32)-1])
+	noncanonical := b32[:len(b32)-1] + string("abcdefghijklmnopqrstuvwxyz234567"[last|1])
+	for _, s := range []string{"", b32[1:], b32 + "a", "1" + b32[1:], noncanonical} {
+		if _, err := whirlpool.ParseBase32(s); err == nil {
+			t.Errorf("ParseBase32(%q) accepted", s)
+		}
+	}
+	for _, s := range []string{"", d.Base64URL()[1:], d.Base64URL() + "A", "+" + d.Base64URL()[1:]} {
+		if _, err := whirlpool.ParseBase64URL(s); err == nil {
+			t.Errorf("ParseBase64URL(%q) accepted", s)
+		}
+	}
+}
+
+func TestEmptyDigestWritable(t *testing.T) {
+	saved := whirlpool.EmptyDigest
+	defer func() { whirlpool.EmptyDigest = saved }()
+
+	whirlpool.EmptyDigest[0] ^= 0xff
+	if s := whirlpool.Sum512(nil).String(); s != strings.ToLower(golden[0].out) {
+		t.Fatalf("changing EmptyDigest changed Sum512(nil) to %s", s)
+	}
+	if !whirlpool.Sum512(nil).IsEmpty() {
+		t.Fatalf("changing EmptyDigest changed IsEmpty")
+	}
+}
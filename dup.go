@@ -0,0 +1,110 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"io"
+	"io/fs"
+	"sort"
+)
+
+// FindDuplicates returns the groups of regular files of fsys with identical
+// contents. To avoid hashing most files in full, files are first grouped by
+// size, then files of equal size by the whirlpool checksum of their first
+// prefixSize bytes, and only files that still collide are hashed in full.
+// A prefixSize of 0 or less skips the prefix pass, so that files of equal
+// size are hashed in full right away. Every group lists at least two paths
+// in lexical order, and the groups are ordered by their first path.
+func FindDuplicates(fsys fs.FS, prefixSize int64) ([][]string, error) {
+	bySize := make(map[int64][]string)
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		bySize[info.Size()] = append(bySize[info.Size()], path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var dups [][]string
+	for size, paths := range bySize {
+		if len(paths) < 2 {
+			continue
+		}
+		candidates := [][]string{paths}
+		if prefixSize > 0 && size > prefixSize {
+			candidates, err = groupBySum(fsys, paths, prefixSize)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, c := range candidates {
+			groups, err := groupBySum(fsys, c, -1)
+			if err != nil {
+				return nil, err
+			}
+			dups = append(dups, groups...)
+		}
+	}
+	sort.Slice(dups, func(i, j int) bool { return dups[i][0] < dups[j][0] })
+	return dups, nil
+}
+
+// groupBySum groups paths by the checksum of their first n bytes, or of
+// their whole contents if n is negative, and returns the groups of at least
+// two paths, preserving the order of paths within each group.
+func groupBySum(fsys fs.FS, paths []string, n int64) ([][]string, error) {
+	var (
+		order []string
+		bySum = make(map[string][]string)
+	)
+	for _, p := range paths {
+		sum, err := sumPrefixFS(fsys, p, n)
+		if err != nil {
+			return nil, err
+		}
+		k := string(sum)
+		if _, ok := bySum[k]; !ok {
+			order = append(order, k)
+		}
+		bySum[k] = append(bySum[k], p)
+	}
+
+	var groups [][]string
+	for _, k := range order {
+		if len(bySum[k]) > 1 {
+			groups = append(groups, bySum[k])
+		}
+	}
+	return groups, nil
+}
+
+// sumPrefixFS returns the checksum of the first n bytes of the file name in
+// fsys, or of the whole file if n is negative.
+func sumPrefixFS(fsys fs.FS, name string, n int64) ([]byte, error) {
+	if n < 0 {
+		return sumFS(fsys, name)
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	w := New()
+	if _, err := io.CopyN(w, f, n); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return w.Sum(nil), nil
+}
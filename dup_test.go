@@ -0,0 +1,72 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	big := strings.Repeat("x", 5000)
+	fsys := fstest.MapFS{
+		"a/one.txt":      {Data: []byte("same")},
+		"b/one-copy.txt": {Data: []byte("same")},
+		"c/other.txt":    {Data: []byte("diff")}, // Same size, different content.
+		"big1":           {Data: []byte(big + "1")},
+		"big2":           {Data: []byte(big + "2")}, // Same size and prefix.
+		"big3":           {Data: []byte(big + "1")},
+		"empty1":         {Data: nil},
+		"empty2":         {Data: nil},
+		"unique":         {Data: []byte("unique size")},
+	}
+
+	for _, prefix := range []int64{-1, 0, 16, 4096, 1 << 20} {
+		got, err := whirlpool.FindDuplicates(fsys, prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "[[a/one.txt b/one-copy.txt] [big1 big3] [empty1 empty2]]"
+		if s := fmt.Sprint(got); s != want {
+			t.Fatalf("prefix %d: got %s want %s", prefix, s, want)
+		}
+	}
+}
+
+// openCountFS counts how often every file is opened.
+type openCountFS struct {
+	fs.FS
+	opens map[string]int
+}
+
+func (c *openCountFS) Open(name string) (fs.File, error) {
+	c.opens[name]++
+	return c.FS.Open(name)
+}
+
+func TestFindDuplicatesNoPrefix(t *testing.T) {
+	files := fstest.MapFS{
+		"a": {Data: []byte("same")},
+		"b": {Data: []byte("same")},
+		"c": {Data: []byte("diff")},
+		"d": {Data: []byte("other size")},
+	}
+	for _, prefix := range []int64{-1, 0} {
+		fsys := &openCountFS{FS: files, opens: make(map[string]int)}
+		if _, err := whirlpool.FindDuplicates(fsys, prefix); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"a", "b", "c"} {
+			if n := fsys.opens[name]; n != 1 {
+				t.Fatalf("prefix %d: %s opened %d times want once", prefix, name, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// calibrationTime is how long Calibrate spends hashing.
+const calibrationTime = 20 * time.Millisecond
+
+var (
+	calibrateOnce sync.Once
+	blockCost     atomic.Uint64 // float64 bits of the cost of a block in ns.
+)
+
+// Calibrate measures how fast whirlpool runs on this machine and returns the
+// throughput in bytes per second. EstimateDuration uses the latest
+// measurement. Calibrate takes a few tens of milliseconds and can be called
+// again to refresh the measurement, for example after the machine's load
+// changed.
+func Calibrate() float64 {
+	cost := measureBlockCost()
+	blockCost.Store(math.Float64bits(cost))
+	return wblockBytes / cost * 1e9
+}
+
+// measureBlockCost returns the time it takes to hash a block in nanoseconds.
+func measureBlockCost() float64 {
+	var (
+		w      whirlpool
+		buf    [64 * wblockBytes]byte
+		blocks int
+		start  = time.Now()
+	)
+	for time.Since(start) < calibrationTime {
+		w.Write(buf[:])
+		blocks += len(buf) / wblockBytes
+	}
+	return float64(time.Since(start).Nanoseconds()) / float64(blocks)
+}
+
+// EstimateDuration predicts how long hashing n bytes takes, including
+// padding and finalization, based on the throughput measured by Calibrate.
+// If Calibrate has not been called yet, EstimateDuration calls it first.
+func EstimateDuration(n int64) time.Duration {
+	calibrateOnce.Do(func() {
+		if blockCost.Load() == 0 {
+			Calibrate()
+		}
+	})
+
+	cost := math.Float64frombits(blockCost.Load())
+	// The padding and length take up to two more blocks.
+	blocks := n/wblockBytes + 2
+	return time.Duration(float64(blocks) * cost)
+}
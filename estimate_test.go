@@ -0,0 +1,25 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestEstimateDuration(t *testing.T) {
+	small := whirlpool.EstimateDuration(0)
+	if small <= 0 {
+		t.Fatalf("EstimateDuration(0) = %v, want > 0", small)
+	}
+	large := whirlpool.EstimateDuration(1 << 30)
+	if large <= small {
+		t.Fatalf("EstimateDuration(1GiB) = %v not above EstimateDuration(0) = %v", large, small)
+	}
+	if bps := whirlpool.Calibrate(); bps <= 0 {
+		t.Fatalf("Calibrate() = %v bytes/s", bps)
+	}
+}
@@ -0,0 +1,15 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+// ForceBitPath makes Write hash byte-aligned input through the
+// bit-oriented path that otherwise only WriteBits reaches, and returns a
+// function that restores the previous setting. Tests using it must not
+// run in parallel with others.
+func ForceBitPath(on bool) (restore func()) {
+	old := forceBitPath
+	forceBitPath = on
+	return func() { forceBitPath = old }
+}
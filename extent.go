@@ -0,0 +1,103 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"errors"
+	"io"
+)
+
+// SumExtents reads r until EOF and returns, in a single pass, the whirlpool
+// checksum of the whole stream and the checksums of consecutive extents of
+// extentSize bytes. The last extent is shorter if the stream length is not a
+// multiple of extentSize; an empty stream has no extents.
+func SumExtents(r io.Reader, extentSize int64) (sum Digest, extents []Digest, err error) {
+	if extentSize <= 0 {
+		return Digest{}, nil, errors.New("whirlpool: extent size must be positive")
+	}
+
+	var (
+		whole, extent whirlpool
+		fill          int64 // Bytes in the current extent.
+		buf           = make([]byte, 32*1024)
+	)
+	for {
+		n, rerr := r.Read(buf)
+		p := buf[:n]
+		whole.Write(p)
+		for len(p) > 0 {
+			m := extentSize - fill
+			if m > int64(len(p)) {
+				m = int64(len(p))
+			}
+			extent.Write(p[:m])
+			fill += m
+			p = p[m:]
+			if fill == extentSize {
+				extents = append(extents, extent.checkSum())
+				extent.Reset()
+				fill = 0
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return Digest{}, nil, rerr
+		}
+	}
+	if fill > 0 {
+		extents = append(extents, extent.checkSum())
+	}
+	return whole.checkSum(), extents, nil
+}
+
+// ByteRange is the range of Length bytes starting at Offset.
+type ByteRange struct {
+	Offset int64
+	Length int64
+}
+
+// ChangedRanges reads a new version of a file from r and compares it, block
+// by block, with the per-block checksums of an older version, as returned
+// in the extents of SumExtents for the same blockSize. It returns the
+// ranges of the new version, with adjacent blocks merged, that differ from
+// the old version and would need to be transferred, in a single pass.
+func ChangedRanges(r io.Reader, blockSize int64, old []Digest) ([]ByteRange, error) {
+	cr := &countingReader{r: r}
+	_, extents, err := SumExtents(cr, blockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges []ByteRange
+	for i, e := range extents {
+		if i < len(old) && e == old[i] {
+			continue
+		}
+		off := int64(i) * blockSize
+		n := blockSize
+		if off+n > cr.n {
+			n = cr.n - off
+		}
+		if last := len(ranges) - 1; last >= 0 && ranges[last].Offset+ranges[last].Length == off {
+			ranges[last].Length += n
+		} else {
+			ranges = append(ranges, ByteRange{Offset: off, Length: n})
+		}
+	}
+	return ranges, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
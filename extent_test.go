@@ -0,0 +1,97 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/tdx/whirlpool"
+)
+
+func sum(data []byte) []byte {
+	h := whirlpool.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func TestSumExtents(t *testing.T) {
+	data := []byte(strings.Repeat("0123456789", 1000))
+
+	for _, size := range []int64{1, 7, 64, 1000, 4096, 10000, 20000} {
+		r := iotest.HalfReader(bytes.NewReader(data))
+		whole, extents, err := whirlpool.SumExtents(r, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if whole != whirlpool.Sum512(data) {
+			t.Fatalf("size %d: whole digest mismatch", size)
+		}
+		want := (int64(len(data)) + size - 1) / size
+		if int64(len(extents)) != want {
+			t.Fatalf("size %d: got %d extents want %d", size, len(extents), want)
+		}
+		for i, e := range extents {
+			end := (int64(i) + 1) * size
+			if end > int64(len(data)) {
+				end = int64(len(data))
+			}
+			if e != whirlpool.Sum512(data[int64(i)*size:end]) {
+				t.Fatalf("size %d: extent %d mismatch", size, i)
+			}
+		}
+	}
+}
+
+func TestSumExtentsEmpty(t *testing.T) {
+	whole, extents, err := whirlpool.SumExtents(bytes.NewReader(nil), 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(extents) != 0 {
+		t.Fatalf("got %d extents for empty input", len(extents))
+	}
+	if whole != whirlpool.Sum512(nil) {
+		t.Fatalf("empty digest mismatch")
+	}
+	if _, _, err := whirlpool.SumExtents(bytes.NewReader(nil), 0); err == nil {
+		t.Fatalf("zero extent size accepted")
+	}
+}
+
+func TestChangedRanges(t *testing.T) {
+	old := []byte(strings.Repeat("abcdefgh", 16)) // 128 bytes, 8 blocks of 16.
+	_, sigs, err := whirlpool.SumExtents(bytes.NewReader(old), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cur := append([]byte{}, old...)
+	cur[20] = 'X'  // Block 1.
+	cur[40] = 'X'  // Block 2.
+	cur[100] = 'X' // Block 6.
+	cur = append(cur, "appended"...)
+
+	got, err := whirlpool.ChangedRanges(bytes.NewReader(cur), 16, sigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []whirlpool.ByteRange{{16, 32}, {96, 16}, {128, 8}}
+	if len(got) != len(want) {
+		t.Fatalf("got ranges %v want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got ranges %v want %v", got, want)
+		}
+	}
+
+	same, err := whirlpool.ChangedRanges(bytes.NewReader(old), 16, sigs)
+	if err != nil || len(same) != 0 {
+		t.Fatalf("unchanged file: got %v, %v", same, err)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ExtractTar extracts the tar stream r into the directory dir, verifying
+// every regular file against its checksum in manifest, as read by
+// ParseSignatures, while it is written. Each file is only moved into place
+// once it matches, as with CopyVerified, and extraction stops at the first
+// file that does not match or is not listed, so that no corrupt file is
+// ever written to dir. Directories are created as needed; other member
+// types, such as links, are skipped.
+//
+// ExtractTar returns an error wrapping ErrMismatch and naming the member if
+// a file does not match, and an error if a file listed in manifest is
+// missing from the archive.
+func ExtractTar(r io.Reader, dir string, manifest []Signature) error {
+	x := newExtractor(dir, manifest)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return x.finish()
+		}
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := x.dir(hdr.Name); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := x.file(hdr.Name, tr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ExtractZip is ExtractTar for the zip archive r of size bytes.
+func ExtractZip(r io.ReaderAt, size int64, dir string, manifest []Signature) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	x := newExtractor(dir, manifest)
+	for _, f := range zr.File {
+		switch {
+		case f.Mode().IsDir():
+			err = x.dir(f.Name)
+		case f.Mode().IsRegular():
+			var rc io.ReadCloser
+			if rc, err = f.Open(); err == nil {
+				err = x.file(f.Name, rc)
+				rc.Close()
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return x.finish()
+}
+
+// extractor writes verified archive members below a directory.
+type extractor struct {
+	root string
+	sums map[string][]byte // Manifest entries not yet extracted.
+}
+
+func newExtractor(dir string, manifest []Signature) *extractor {
+	x := &extractor{root: dir, sums: make(map[string][]byte, len(manifest))}
+	for _, s := range manifest {
+		x.sums[path.Clean(strings.TrimPrefix(s.Path, "./"))] = s.Sum
+	}
+	return x
+}
+
+// target returns where the member name goes, refusing names that would
+// escape the directory.
+func (x *extractor) target(name string) (string, string, error) {
+	clean := path.Clean(strings.TrimPrefix(name, "./"))
+	if !filepath.IsLocal(filepath.FromSlash(clean)) {
+		return "", "", fmt.Errorf("whirlpool: archive member %q escapes the target directory", name)
+	}
+	return clean, filepath.Join(x.root, filepath.FromSlash(clean)), nil
+}
+
+func (x *extractor) dir(name string) error {
+	_, dst, err := x.target(name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(dst, 0755)
+}
+
+func (x *extractor) file(name string, r io.Reader) error {
+	clean, dst, err := x.target(name)
+	if err != nil {
+		return err
+	}
+	sum, ok := x.sums[clean]
+	if !ok {
+		return fmt.Errorf("whirlpool: archive member %s is not in the manifest", clean)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if _, err := CopyVerified(dst, r, sum); err != nil {
+		return fmt.Errorf("%s: %w", clean, err)
+	}
+	delete(x.sums, clean)
+	return nil
+}
+
+func (x *extractor) finish() error {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(x.sums)) {
+		errs = append(errs, fmt.Errorf("whirlpool: %s is missing from the archive", name))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,128 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+var archiveFiles = []struct{ name, body string }{
+	{"README", "read me"},
+	{"src/main.go", "package main"},
+	{"src/lib/lib.go", "package lib"},
+}
+
+func archiveManifest() []whirlpool.Signature {
+	var m []whirlpool.Signature
+	for _, f := range archiveFiles {
+		m = append(m, whirlpool.Signature{Path: f.name, Sum: sum([]byte(f.body))})
+	}
+	return m
+}
+
+func makeTar(t *testing.T, corrupt string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "src/", Typeflag: tar.TypeDir, Mode: 0755})
+	for _, f := range archiveFiles {
+		body := f.body
+		if f.name == corrupt {
+			body = strings.ToUpper(body)
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0644, Size: int64(len(body))}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(body))
+	}
+	tw.Close()
+	return buf.Bytes()
+}
+
+func makeZip(t *testing.T, corrupt string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range archiveFiles {
+		body := f.body
+		if f.name == corrupt {
+			body = strings.ToUpper(body)
+		}
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestExtract(t *testing.T) {
+	for name, extract := range map[string]func(dir, corrupt string) error{
+		"tar": func(dir, corrupt string) error {
+			return whirlpool.ExtractTar(bytes.NewReader(makeTar(t, corrupt)), dir, archiveManifest())
+		},
+		"zip": func(dir, corrupt string) error {
+			z := makeZip(t, corrupt)
+			return whirlpool.ExtractZip(bytes.NewReader(z), int64(len(z)), dir, archiveManifest())
+		},
+	} {
+		dir := t.TempDir()
+		if err := extract(dir, ""); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, f := range archiveFiles {
+			got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(f.name)))
+			if err != nil || string(got) != f.body {
+				t.Fatalf("%s: %s = %q, %v", name, f.name, got, err)
+			}
+		}
+
+		dir = t.TempDir()
+		err := extract(dir, "src/main.go")
+		if !errors.Is(err, whirlpool.ErrMismatch) || !strings.Contains(err.Error(), "src/main.go") {
+			t.Fatalf("%s: got %v want mismatch of src/main.go", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "src", "main.go")); !os.IsNotExist(err) {
+			t.Fatalf("%s: corrupt file written: %v", name, err)
+		}
+	}
+}
+
+func TestExtractManifestMismatch(t *testing.T) {
+	// A member that is not listed is rejected.
+	err := whirlpool.ExtractTar(bytes.NewReader(makeTar(t, "")), t.TempDir(), archiveManifest()[:2])
+	if err == nil || !strings.Contains(err.Error(), "not in the manifest") {
+		t.Fatalf("got %v want unlisted member error", err)
+	}
+
+	// A listed file missing from the archive is reported.
+	m := append(archiveManifest(), whirlpool.Signature{Path: "LICENSE", Sum: sum(nil)})
+	err = whirlpool.ExtractTar(bytes.NewReader(makeTar(t, "")), t.TempDir(), m)
+	if err == nil || !strings.Contains(err.Error(), "LICENSE is missing") {
+		t.Fatalf("got %v want missing file error", err)
+	}
+}
+
+func TestExtractTraversal(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "../evil", Mode: 0644, Size: 1})
+	tw.Write([]byte("x"))
+	tw.Close()
+
+	m := []whirlpool.Signature{{Path: "../evil", Sum: sum([]byte("x"))}}
+	if err := whirlpool.ExtractTar(&buf, t.TempDir(), m); err == nil || !strings.Contains(err.Error(), "escapes") {
+		t.Fatalf("got %v want traversal error", err)
+	}
+}
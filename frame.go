@@ -0,0 +1,88 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrFrameTooLarge is returned by FrameReader for a frame whose payload
+// exceeds the configured maximum size.
+var ErrFrameTooLarge = errors.New("whirlpool: frame too large")
+
+// FrameWriter writes records in a simple integrity-checked framing: the
+// payload length as an unsigned varint, the payload and the 64-byte
+// whirlpool checksum of the payload.
+type FrameWriter struct {
+	w io.Writer
+}
+
+// NewFrameWriter returns a FrameWriter writing frames to w.
+func NewFrameWriter(w io.Writer) *FrameWriter {
+	return &FrameWriter{w: w}
+}
+
+// WriteFrame writes p as a single frame.
+func (fw *FrameWriter) WriteFrame(p []byte) error {
+	var (
+		w   whirlpool
+		hdr [binary.MaxVarintLen64]byte
+	)
+	n := binary.PutUvarint(hdr[:], uint64(len(p)))
+	if _, err := fw.w.Write(hdr[:n]); err != nil {
+		return err
+	}
+	if _, err := fw.w.Write(p); err != nil {
+		return err
+	}
+	w.Write(p)
+	_, err := fw.w.Write(w.Sum(nil))
+	return err
+}
+
+// FrameReader reads frames written by FrameWriter and verifies them.
+type FrameReader struct {
+	r   *bufio.Reader
+	max uint64
+}
+
+// NewFrameReader returns a FrameReader reading frames from r whose payloads
+// are at most maxSize bytes long.
+func NewFrameReader(r io.Reader, maxSize int) *FrameReader {
+	return &FrameReader{r: bufio.NewReader(r), max: uint64(maxSize)}
+}
+
+// ReadFrame reads the next frame and returns its payload. It returns io.EOF
+// at the end of the stream, io.ErrUnexpectedEOF if the stream ends within a
+// frame and ErrMismatch if the payload does not match its checksum.
+func (fr *FrameReader) ReadFrame() ([]byte, error) {
+	n, err := binary.ReadUvarint(fr.r)
+	if err != nil {
+		return nil, err
+	}
+	if n > fr.max {
+		return nil, ErrFrameTooLarge
+	}
+
+	buf := make([]byte, n+digestBytes)
+	if _, err := io.ReadFull(fr.r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	payload, sum := buf[:n], buf[n:]
+
+	var w whirlpool
+	w.Write(payload)
+	if !bytes.Equal(w.Sum(nil), sum) {
+		return nil, ErrMismatch
+	}
+	return payload[:n:n], nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestFrames(t *testing.T) {
+	records := []string{"", "a", strings.Repeat("spool entry ", 50)}
+
+	var buf bytes.Buffer
+	fw := whirlpool.NewFrameWriter(&buf)
+	for _, r := range records {
+		if err := fw.WriteFrame([]byte(r)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream := buf.Bytes()
+
+	fr := whirlpool.NewFrameReader(bytes.NewReader(stream), 1<<20)
+	for _, want := range records {
+		got, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Fatalf("ReadFrame = %q want %q", got, want)
+		}
+	}
+	if _, err := fr.ReadFrame(); err != io.EOF {
+		t.Fatalf("ReadFrame at end = %v want io.EOF", err)
+	}
+
+	corrupt := append([]byte{}, stream...)
+	corrupt[66] ^= 1 // The payload of the second frame.
+	fr = whirlpool.NewFrameReader(bytes.NewReader(corrupt), 1<<20)
+	fr.ReadFrame()
+	if _, err := fr.ReadFrame(); err != whirlpool.ErrMismatch {
+		t.Fatalf("corrupt frame: got %v want ErrMismatch", err)
+	}
+
+	fr = whirlpool.NewFrameReader(bytes.NewReader(stream[:len(stream)-1]), 1<<20)
+	fr.ReadFrame()
+	fr.ReadFrame()
+	if _, err := fr.ReadFrame(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("truncated frame: got %v want io.ErrUnexpectedEOF", err)
+	}
+
+	fr = whirlpool.NewFrameReader(bytes.NewReader(stream), 10)
+	fr.ReadFrame()
+	fr.ReadFrame()
+	if _, err := fr.ReadFrame(); err != whirlpool.ErrFrameTooLarge {
+		t.Fatalf("large frame: got %v want ErrFrameTooLarge", err)
+	}
+}
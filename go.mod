@@ -1,5 +1,5 @@
 module github.com/tdx/whirlpool
 
-go 1.13
+go 1.25
 
 require github.com/jzelinskie/whirlpool v0.0.0-20170603002051-c19460b8caa6
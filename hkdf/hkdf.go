@@ -0,0 +1,98 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hkdf implements HKDF-Whirlpool, the HMAC-based extract-and-expand
+// key derivation function defined in RFC 5869, with whirlpool as its hash.
+//
+// Extract, Expand and New mirror golang.org/x/crypto/hkdf without the hash
+// parameter. Key adds the common convention of deriving subkeys by a label
+// and a context, to build key hierarchies without composing info strings
+// by hand.
+package hkdf
+
+import (
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/tdx/whirlpool"
+	"github.com/tdx/whirlpool/hmac"
+)
+
+// MaxKeyLen is the largest number of bytes HKDF can derive from one
+// pseudorandom key.
+const MaxKeyLen = 255 * whirlpool.Size
+
+// Extract returns a pseudorandom key for Expand from secret and salt. An
+// empty salt is replaced by whirlpool.Size zero bytes, as RFC 5869
+// specifies.
+func Extract(secret, salt []byte) []byte {
+	if len(salt) == 0 {
+		salt = make([]byte, whirlpool.Size)
+	}
+	h := hmac.New(salt)
+	h.Write(secret)
+	return h.Sum(nil)
+}
+
+// Expand returns a reader of up to MaxKeyLen bytes of key material derived
+// from pseudorandomKey, as returned by Extract, and info, which binds the
+// output to its purpose. Reading beyond MaxKeyLen returns an error.
+func Expand(pseudorandomKey, info []byte) io.Reader {
+	return &expander{prf: hmac.New(pseudorandomKey), info: info}
+}
+
+// New returns a reader of key material derived from secret, salt and info,
+// that is Expand(Extract(secret, salt), info).
+func New(secret, salt, info []byte) io.Reader {
+	return Expand(Extract(secret, salt), info)
+}
+
+// Key derives a key of length bytes from secret and salt for the given
+// label and context, with info set to label || 0x00 || context. The label
+// names the purpose of the key, such as "file encryption", and the context
+// the instance, such as a file ID, so that every combination gets an
+// independent key. It returns an error if length exceeds MaxKeyLen.
+func Key(secret, salt []byte, label string, context []byte, length int) ([]byte, error) {
+	info := make([]byte, 0, len(label)+1+len(context))
+	info = append(append(append(info, label...), 0), context...)
+	key := make([]byte, length)
+	if _, err := io.ReadFull(New(secret, salt, info), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+type expander struct {
+	prf     hash.Hash
+	info    []byte
+	counter byte
+	prev    [whirlpool.Size]byte // T(counter), of which the first used bytes are consumed.
+	used    int
+}
+
+func (e *expander) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if e.counter == 0 || e.used == len(e.prev) {
+			if e.counter == 255 {
+				return n, errors.New("hkdf: entropy limit reached")
+			}
+			// T(i) = HMAC(PRK, T(i-1) || info || i), with T(0) empty.
+			e.prf.Reset()
+			if e.counter > 0 {
+				e.prf.Write(e.prev[:])
+			}
+			e.counter++
+			e.prf.Write(e.info)
+			e.prf.Write([]byte{e.counter})
+			e.prf.Sum(e.prev[:0])
+			e.used = 0
+		}
+		c := copy(p[n:], e.prev[e.used:])
+		e.used += c
+		n += c
+	}
+	return n, nil
+}
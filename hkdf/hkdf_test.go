@@ -0,0 +1,110 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hkdf_test
+
+import (
+	"bytes"
+	stdhkdf "crypto/hkdf"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+	"github.com/tdx/whirlpool/hkdf"
+)
+
+func unhex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// The inputs of RFC 5869 test cases 1 and 3 with whirlpool, as computed by
+// OpenSSL.
+var golden = []struct {
+	secret, salt, info string
+	prk, okm           string
+}{
+	{
+		"0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b",
+		"000102030405060708090a0b0c",
+		"f0f1f2f3f4f5f6f7f8f9",
+		"165B2E2A450052D60B2D8C26A8B9B3FB140575EA189DB969B6599723EAF7AFFDA865364ACAC2D85D57171EE40E8A94818B2AE8957DD495256525F4A71FFDC4C9",
+		"0D29F74CCD8640F44B0DD9638111C1B5766EFED752AF358109E2E7C9CD4A28EF2F90B2AD461FBA0744D4",
+	},
+	{
+		"0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b",
+		"",
+		"",
+		"",
+		"110632D0F7AEFAC31771FC66C22BB3462614B81E4B04BA7F2B662E0BD694F56458615F9A9CB56C57ECF28091C326FAA92F729D25FFB90D234FE498D7EC582EFEB5622154028F28CB91DACDDA936041BA7AF6C59FD1AF01D6BE582E553FCD26CE9F91FAEC5DEC2A32E7EF21AB045FCDF7EA9BD53668606DDD098083E0ABAA0594EC9EF06FC10A68BC965B4643F3A4A9969EB3A37DC09F32EDA5480CF25E2928D8349409C5DB8521F4A3943DB00BE821BD2A6281EAAA57A5FEA2D32B0BEB1DFA9661B7F5C6305A6E90",
+	},
+}
+
+func TestGolden(t *testing.T) {
+	for i, g := range golden {
+		secret, salt, info := unhex(g.secret), unhex(g.salt), unhex(g.info)
+		if prk := fmt.Sprintf("%X", hkdf.Extract(secret, salt)); g.prk != "" && prk != g.prk {
+			t.Errorf("case %d: Extract = %s want %s", i, prk, g.prk)
+		}
+		okm := make([]byte, len(g.okm)/2)
+		if _, err := io.ReadFull(hkdf.New(secret, salt, info), okm); err != nil {
+			t.Fatal(err)
+		}
+		if s := fmt.Sprintf("%X", okm); s != g.okm {
+			t.Errorf("case %d: New = %s want %s", i, s, g.okm)
+		}
+	}
+}
+
+func TestStdlib(t *testing.T) {
+	secret, salt, info := []byte("secret"), []byte("salt"), "info"
+	prk := hkdf.Extract(secret, salt)
+	want, err := stdhkdf.Expand(whirlpool.New, prk, info, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read in odd sizes to cross block boundaries.
+	r := hkdf.Expand(prk, []byte(info))
+	var got []byte
+	for n := 1; len(got) < len(want); n += 7 {
+		buf := make([]byte, min(n, len(want)-len(got)))
+		if _, err := io.ReadFull(r, buf); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, buf...)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got %x want %x", got, want)
+	}
+}
+
+func TestKey(t *testing.T) {
+	secret := []byte("master")
+	k, err := hkdf.Key(secret, nil, "file encryption", []byte("file 7"), 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make([]byte, 32)
+	io.ReadFull(hkdf.New(secret, nil, []byte("file encryption\x00file 7")), want)
+	if !bytes.Equal(k, want) {
+		t.Fatalf("Key = %x want %x", k, want)
+	}
+	other, _ := hkdf.Key(secret, nil, "file encryption", []byte("file 8"), 32)
+	if bytes.Equal(k, other) {
+		t.Fatalf("different contexts gave the same key")
+	}
+
+	if _, err := hkdf.Key(secret, nil, "too long", nil, hkdf.MaxKeyLen); err != nil {
+		t.Fatalf("MaxKeyLen bytes: %v", err)
+	}
+	if _, err := hkdf.Key(secret, nil, "too long", nil, hkdf.MaxKeyLen+1); err == nil {
+		t.Fatalf("more than MaxKeyLen bytes derived")
+	}
+}
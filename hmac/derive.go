@@ -0,0 +1,43 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hmac
+
+import (
+	"encoding/binary"
+
+	"github.com/tdx/whirlpool"
+)
+
+// Labels that separate the key derivations from each other and from other
+// uses of the master key.
+const (
+	fileKeyLabel  = "whirlpool file key\x00"
+	chunkKeyLabel = "whirlpool chunk key\x00"
+)
+
+// FileKey derives the MAC key of the file at path from master as
+// HMAC-Whirlpool(master, "whirlpool file key" || 0x00 || path). Storage
+// systems can use it to authenticate files individually while only keeping
+// master secret; knowing the key of one file reveals nothing about the
+// keys of the others. Callers should use one canonical form of path, such
+// as slash-separated and relative to the root of the store.
+func FileKey(master []byte, path string) []byte {
+	h := New(master)
+	h.Write([]byte(fileKeyLabel))
+	h.Write([]byte(path))
+	return h.Sum(make([]byte, 0, whirlpool.Size))
+}
+
+// ChunkKey derives the MAC key of the chunk with the given index from the
+// key of its file, as returned by FileKey, as
+// HMAC-Whirlpool(fileKey, "whirlpool chunk key" || 0x00 || index), with the
+// index as a 64-bit big-endian integer. Whoever holds the key of a file can
+// thus check its chunks, but not those of other files.
+func ChunkKey(fileKey []byte, index uint64) []byte {
+	h := New(fileKey)
+	h.Write([]byte(chunkKeyLabel))
+	h.Write(binary.BigEndian.AppendUint64(nil, index))
+	return h.Sum(make([]byte, 0, whirlpool.Size))
+}
@@ -0,0 +1,45 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hmac_test
+
+import (
+	"bytes"
+	stdhmac "crypto/hmac"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+	"github.com/tdx/whirlpool/hmac"
+)
+
+func TestFileKey(t *testing.T) {
+	master := []byte("master key")
+	std := stdhmac.New(whirlpool.New, master)
+	std.Write([]byte("whirlpool file key\x00dir/file.txt"))
+	fk := hmac.FileKey(master, "dir/file.txt")
+	if !bytes.Equal(fk, std.Sum(nil)) {
+		t.Fatalf("FileKey = %x want %x", fk, std.Sum(nil))
+	}
+
+	std = stdhmac.New(whirlpool.New, fk)
+	std.Write([]byte("whirlpool chunk key\x00\x00\x00\x00\x00\x00\x00\x01\x02"))
+	if ck := hmac.ChunkKey(fk, 0x102); !bytes.Equal(ck, std.Sum(nil)) {
+		t.Fatalf("ChunkKey = %x want %x", ck, std.Sum(nil))
+	}
+
+	seen := make(map[string]bool)
+	for _, k := range [][]byte{
+		fk,
+		hmac.FileKey(master, "dir/file.txt2"),
+		hmac.FileKey([]byte("other master"), "dir/file.txt"),
+		hmac.ChunkKey(fk, 0),
+		hmac.ChunkKey(fk, 1),
+		hmac.ChunkKey(hmac.FileKey(master, "other"), 1),
+	} {
+		if seen[string(k)] {
+			t.Fatalf("key %x derived twice", k)
+		}
+		seen[string(k)] = true
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hmac_test
+
+import (
+	"bytes"
+	stdhmac "crypto/hmac"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+	"github.com/tdx/whirlpool/hmac"
+)
+
+// The cases of RFC 4231 applied to HMAC-Whirlpool, plus a key of exactly
+// one block and an empty key, as computed by OpenSSL.
+var golden = []struct {
+	key, in, out string
+}{
+	{
+		strings.Repeat("\x0b", 20),
+		"Hi There",
+		"8A2C9B1CCF4B28660DE78AF9DB15B7C94D129EC960CA9A950A665EA5E88362E24F4474354E18512D956D9BB7E6BBBB50B9BA0D3093B0A17C6EC2AA91E57169CE",
+	},
+	{
+		"Jefe",
+		"what do ya want for nothing?",
+		"3D595CCD1D4F4CFD045AF53BA7D5C8283FEE6DED6EAF1269071B6B4EA64800056B5077C6A942CFA1221BD4E5AED791276E5DD46A407D2B8007163D3E7CD1DE66",
+	},
+	{
+		strings.Repeat("\xaa", 20),
+		strings.Repeat("\xdd", 50),
+		"EA252F252E230E3D1950CF44679E31D9DE70D1DEC6F41DBE38A12D76E2B54CFFA2637F0408A48A0A387315EF1118055D373DC295BBA3563276F846A0957FB823",
+	},
+	{
+		"\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c\x0d\x0e\x0f\x10\x11\x12\x13\x14\x15\x16\x17\x18\x19",
+		strings.Repeat("\xcd", 50),
+		"35BC33E2ED71E1CB01C140DDD3291AE3F84E9F0DCE18005A1123DF199983A211FE744B244449A1C093B17584069359BC6A95352271D78E2EF7A6F21DC28AB3C1",
+	},
+	{
+		strings.Repeat("\xaa", 131),
+		"Test Using Larger Than Block-Size Key - Hash Key First",
+		"BF0C49CA78D52E92357E0FF1C2978F8820C9B4BCBBF5118179CA40385D51BD78956D5A3BA7010EFFEBCBAF5C431F1757742982BDEB69E6BFB415151AB2C2B43F",
+	},
+	{
+		strings.Repeat("\xaa", 64),
+		"exactly one block of key",
+		"85BF08F8773CA7F9CC719B5328D9D729FFC164C53562D3DE471E04AADAA14DA5702DDBD93A0D232C04A6CBE4F9F199A1DA0028E5980FDFEFA1CED327269A6A79",
+	},
+	{
+		"",
+		"",
+		"57D739903190550DEFA77309FF7B72406A927BBC54E8FCDC98E145FA4C36CE83A9CF1605AD01E0D1925F93AC1D12B985A26044E9FB1B9CCE24301FAA76EAAB53",
+	},
+}
+
+func TestGolden(t *testing.T) {
+	for i, g := range golden {
+		h := hmac.New([]byte(g.key))
+		// Sum and Reset must leave the keyed states intact.
+		for j := 0; j < 3; j++ {
+			h.Write([]byte(g.in))
+			if s := fmt.Sprintf("%X", h.Sum(nil)); s != g.out {
+				t.Fatalf("case %d, pass %d: got %s want %s", i, j, s, g.out)
+			}
+			h.Reset()
+		}
+	}
+}
+
+func TestStdlib(t *testing.T) {
+	key := []byte("key")
+	h, std := hmac.New(key), stdhmac.New(whirlpool.New, key)
+	for i := 0; i < 300; i++ {
+		msg := bytes.Repeat([]byte{byte(i)}, i)
+		h.Write(msg)
+		std.Write(msg)
+		if got, want := h.Sum([]byte("prefix")), std.Sum([]byte("prefix")); !bytes.Equal(got, want) {
+			t.Fatalf("after %d writes: got %s want %s", i+1, hex.EncodeToString(got), hex.EncodeToString(want))
+		}
+	}
+}
+
+func TestAllocs(t *testing.T) {
+	h := hmac.New([]byte("key"))
+	msg := []byte("message")
+	var out [whirlpool.Size]byte
+	n := testing.AllocsPerRun(100, func() {
+		h.Reset()
+		h.Write(msg)
+		h.Sum(out[:0])
+	})
+	if n > 0 {
+		t.Fatalf("got %v allocs per MAC want 0", n)
+	}
+}
+
+func BenchmarkShortMessage(b *testing.B) {
+	h := hmac.New([]byte("key"))
+	msg := make([]byte, 32)
+	var out [whirlpool.Size]byte
+	b.SetBytes(int64(len(msg)))
+	for b.Loop() {
+		h.Reset()
+		h.Write(msg)
+		h.Sum(out[:0])
+	}
+}
+
+func BenchmarkShortMessageStdlib(b *testing.B) {
+	h := stdhmac.New(whirlpool.New, []byte("key"))
+	msg := make([]byte, 32)
+	var out [whirlpool.Size]byte
+	b.SetBytes(int64(len(msg)))
+	for b.Loop() {
+		h.Reset()
+		h.Write(msg)
+		h.Sum(out[:0])
+	}
+}
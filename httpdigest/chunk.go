@@ -0,0 +1,208 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package httpdigest provides HTTP helpers that attach whirlpool digests to
+// response bodies and verify them.
+package httpdigest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tdx/whirlpool"
+)
+
+// Header and trailer of the chunk digest scheme. A response announces the
+// scheme with a ChunkSizeHeader holding the chunk size in bytes, and
+// delivers the lowercase hex whirlpool checksums of the consecutive chunks
+// of its body, the last of which may be shorter, comma-separated in the
+// ChunkDigestsTrailer.
+const (
+	ChunkSizeHeader     = "Whirlpool-Chunk-Size"
+	ChunkDigestsTrailer = "Whirlpool-Chunk-Digests"
+)
+
+// ChunkError is returned when reading a response body whose chunk digests
+// do not match.
+type ChunkError struct {
+	Chunk int // Index of the first bad chunk, or -1 if the trailer is missing or malformed.
+}
+
+func (e *ChunkError) Error() string {
+	if e.Chunk < 0 {
+		return "httpdigest: missing or malformed " + ChunkDigestsTrailer + " trailer"
+	}
+	return fmt.Sprintf("httpdigest: chunk %d does not match its digest", e.Chunk)
+}
+
+// chunker hashes a stream in fixed-size chunks.
+type chunker struct {
+	size int64
+	fill int64
+	h    hash.Hash
+	sums [][]byte
+}
+
+func newChunker(size int64) *chunker {
+	return &chunker{size: size, h: whirlpool.New()}
+}
+
+func (c *chunker) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		m := c.size - c.fill
+		if m > int64(len(p)) {
+			m = int64(len(p))
+		}
+		c.h.Write(p[:m])
+		c.fill += m
+		p = p[m:]
+		if c.fill == c.size {
+			c.flush()
+		}
+	}
+	return n, nil
+}
+
+func (c *chunker) flush() {
+	c.sums = append(c.sums, c.h.Sum(nil))
+	c.h.Reset()
+	c.fill = 0
+}
+
+// finish returns the digests of all chunks, including a final partial one.
+func (c *chunker) finish() [][]byte {
+	if c.fill > 0 {
+		c.flush()
+	}
+	return c.sums
+}
+
+// ChunkDigests wraps h so that its responses carry chunk digests of the
+// given chunk size. Trailers are only sent with chunked transfer encoding,
+// so h must not set a Content-Length. It panics if chunkSize < 1.
+func ChunkDigests(h http.Handler, chunkSize int) http.Handler {
+	if chunkSize < 1 {
+		panic("httpdigest: chunk size must be positive")
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(ChunkSizeHeader, strconv.Itoa(chunkSize))
+		w.Header().Add("Trailer", ChunkDigestsTrailer)
+
+		cw := &chunkWriter{ResponseWriter: w, c: newChunker(int64(chunkSize))}
+		h.ServeHTTP(cw, r)
+
+		sums := cw.c.finish()
+		hexSums := make([]string, len(sums))
+		for i, s := range sums {
+			hexSums[i] = hex.EncodeToString(s)
+		}
+		w.Header().Set(ChunkDigestsTrailer, strings.Join(hexSums, ","))
+	})
+}
+
+type chunkWriter struct {
+	http.ResponseWriter
+	c *chunker
+}
+
+func (cw *chunkWriter) Write(p []byte) (int, error) {
+	n, err := cw.ResponseWriter.Write(p)
+	cw.c.Write(p[:n])
+	return n, err
+}
+
+// Flush lets streaming handlers flush through the wrapper.
+func (cw *chunkWriter) Flush() {
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Transport is an http.RoundTripper that verifies the chunk digests and body
+// digests of responses that announce them, for example in a reverse proxy.
+// Reading such a body returns a *ChunkError or ErrDigest instead of io.EOF
+// if the body does not match the digests in its trailers, so that the
+// corruption is not passed on as a complete response. Other responses are
+// passed through unchanged.
+type Transport struct {
+	// Base is the underlying RoundTripper; http.DefaultTransport if nil.
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	size, err := strconv.ParseInt(resp.Header.Get(ChunkSizeHeader), 10, 64)
+	if err == nil && size > 0 {
+		resp.Body = &chunkVerifier{body: resp.Body, resp: resp, c: newChunker(size)}
+	}
+	if _, ok := resp.Trailer[DigestTrailer]; ok {
+		resp.Body = &digestVerifier{body: resp.Body, resp: resp, h: whirlpool.New()}
+	}
+	return resp, nil
+}
+
+type chunkVerifier struct {
+	body io.ReadCloser
+	resp *http.Response
+	c    *chunker
+	err  error
+}
+
+func (v *chunkVerifier) Read(p []byte) (int, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+	n, err := v.body.Read(p)
+	v.c.Write(p[:n])
+	if err == io.EOF {
+		err = v.verify()
+	}
+	v.err = err
+	return n, err
+}
+
+// verify compares the chunk digests against the trailer, which is only
+// available once the body has been read.
+func (v *chunkVerifier) verify() error {
+	got := v.c.finish()
+	var want []string
+	if t := v.resp.Trailer.Get(ChunkDigestsTrailer); t != "" {
+		want = strings.Split(t, ",")
+	}
+	for i, g := range got {
+		if i >= len(want) {
+			return &ChunkError{Chunk: -1}
+		}
+		w, err := hex.DecodeString(strings.TrimSpace(want[i]))
+		if err != nil {
+			return &ChunkError{Chunk: -1}
+		}
+		if !bytes.Equal(g, w) {
+			return &ChunkError{Chunk: i}
+		}
+	}
+	if len(want) != len(got) {
+		return &ChunkError{Chunk: -1}
+	}
+	return io.EOF
+}
+
+func (v *chunkVerifier) Close() error {
+	return v.body.Close()
+}
@@ -0,0 +1,120 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpdigest_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tdx/whirlpool/httpdigest"
+)
+
+var body = strings.Repeat("streamed response body\n", 200)
+
+func streamBody(w http.ResponseWriter, r *http.Request) {
+	for i := 0; i < len(body); i += 1000 {
+		end := i + 1000
+		if end > len(body) {
+			end = len(body)
+		}
+		io.WriteString(w, body[i:end])
+		w.(http.Flusher).Flush()
+	}
+}
+
+// corrupt flips a bit of the byte at offset 1500 after the digests have
+// been computed, like a broken origin or intermediary would.
+func corrupt(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(&corruptWriter{ResponseWriter: w}, r)
+	})
+}
+
+type corruptWriter struct {
+	http.ResponseWriter
+	off int
+}
+
+func (c *corruptWriter) Write(p []byte) (int, error) {
+	q := append([]byte{}, p...)
+	if i := 1500 - c.off; i >= 0 && i < len(q) {
+		q[i] ^= 1
+	}
+	c.off += len(p)
+	return c.ResponseWriter.Write(q)
+}
+
+func (c *corruptWriter) Flush() {
+	c.ResponseWriter.(http.Flusher).Flush()
+}
+
+func get(t *testing.T, h http.Handler) (string, error) {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	client := &http.Client{Transport: &httpdigest.Transport{}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return string(data), err
+}
+
+func TestChunkDigests(t *testing.T) {
+	data, err := get(t, httpdigest.ChunkDigests(http.HandlerFunc(streamBody), 512))
+	if err != nil {
+		t.Fatalf("reading verified body: %v", err)
+	}
+	if data != body {
+		t.Fatalf("body mangled")
+	}
+}
+
+func TestChunkDigestsCorrupt(t *testing.T) {
+	_, err := get(t, corrupt(httpdigest.ChunkDigests(http.HandlerFunc(streamBody), 512)))
+	var ce *httpdigest.ChunkError
+	if !errors.As(err, &ce) || ce.Chunk != 2 {
+		t.Fatalf("got %v want error for chunk 2", err)
+	}
+}
+
+func TestChunkDigestsMissingTrailer(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(httpdigest.ChunkSizeHeader, "512")
+		streamBody(w, r)
+	})
+	_, err := get(t, h)
+	var ce *httpdigest.ChunkError
+	if !errors.As(err, &ce) || ce.Chunk != -1 {
+		t.Fatalf("got %v want missing trailer error", err)
+	}
+}
+
+func TestTransportPassThrough(t *testing.T) {
+	data, err := get(t, http.HandlerFunc(streamBody))
+	if err != nil || data != body {
+		t.Fatalf("plain response not passed through: %v", err)
+	}
+}
+
+func TestChunkDigestsInvalidSize(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("ChunkDigests with chunk size %d did not panic", size)
+				}
+			}()
+			httpdigest.ChunkDigests(http.HandlerFunc(streamBody), size)
+		}()
+	}
+}
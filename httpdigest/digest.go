@@ -0,0 +1,84 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpdigest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tdx/whirlpool"
+)
+
+// DigestTrailer is the trailer holding the lowercase hex whirlpool checksum
+// of the whole response body.
+const DigestTrailer = "Whirlpool-Digest"
+
+// ErrDigest is returned when reading a response body that does not match
+// its DigestTrailer, or whose announced trailer is missing.
+var ErrDigest = errors.New("httpdigest: body does not match " + DigestTrailer + " trailer")
+
+// Digest wraps h so that its responses carry the checksum of their body in
+// a DigestTrailer, computed while the body streams out. Like ChunkDigests,
+// it requires h not to set a Content-Length.
+func Digest(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Trailer", DigestTrailer)
+
+		dw := &digestWriter{ResponseWriter: w, h: whirlpool.New()}
+		h.ServeHTTP(dw, r)
+
+		w.Header().Set(DigestTrailer, hex.EncodeToString(dw.h.Sum(nil)))
+	})
+}
+
+type digestWriter struct {
+	http.ResponseWriter
+	h hash.Hash
+}
+
+func (dw *digestWriter) Write(p []byte) (int, error) {
+	n, err := dw.ResponseWriter.Write(p)
+	dw.h.Write(p[:n])
+	return n, err
+}
+
+// Flush lets streaming handlers flush through the wrapper.
+func (dw *digestWriter) Flush() {
+	if f, ok := dw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+type digestVerifier struct {
+	body io.ReadCloser
+	resp *http.Response
+	h    hash.Hash
+	err  error
+}
+
+func (v *digestVerifier) Read(p []byte) (int, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+	n, err := v.body.Read(p)
+	v.h.Write(p[:n])
+	if err == io.EOF {
+		want, derr := hex.DecodeString(strings.TrimSpace(v.resp.Trailer.Get(DigestTrailer)))
+		if derr != nil || !bytes.Equal(v.h.Sum(nil), want) {
+			err = ErrDigest
+		}
+	}
+	v.err = err
+	return n, err
+}
+
+func (v *digestVerifier) Close() error {
+	return v.body.Close()
+}
@@ -0,0 +1,46 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpdigest_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/tdx/whirlpool/httpdigest"
+)
+
+func TestDigest(t *testing.T) {
+	data, err := get(t, httpdigest.Digest(http.HandlerFunc(streamBody)))
+	if err != nil {
+		t.Fatalf("reading verified body: %v", err)
+	}
+	if data != body {
+		t.Fatalf("body mangled")
+	}
+}
+
+func TestDigestCorrupt(t *testing.T) {
+	if _, err := get(t, corrupt(httpdigest.Digest(http.HandlerFunc(streamBody)))); err != httpdigest.ErrDigest {
+		t.Fatalf("got %v want ErrDigest", err)
+	}
+}
+
+func TestDigestMissingTrailer(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Trailer", httpdigest.DigestTrailer)
+		streamBody(w, r)
+	})
+	if _, err := get(t, h); err != httpdigest.ErrDigest {
+		t.Fatalf("got %v want ErrDigest", err)
+	}
+}
+
+func TestDigestWithChunkDigests(t *testing.T) {
+	h := httpdigest.Digest(httpdigest.ChunkDigests(http.HandlerFunc(streamBody), 512))
+	data, err := get(t, h)
+	if err != nil || data != body {
+		t.Fatalf("reading doubly verified body: %v", err)
+	}
+}
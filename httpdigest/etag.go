@@ -0,0 +1,98 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpdigest
+
+import (
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tdx/whirlpool"
+)
+
+// etagEntry is a cached ETag, valid while the file keeps its size and
+// modification time.
+type etagEntry struct {
+	size    int64
+	modTime time.Time
+	etag    string
+}
+
+// ETags wraps h, typically an http.FileServer for fsys, so that GET and HEAD
+// responses for regular files carry a strong ETag derived from the whirlpool
+// checksum of the file, and requests whose If-None-Match matches it are
+// answered with 304 Not Modified without calling h.
+//
+// The request path, stripped of its leading slash, names the file in fsys.
+// Checksums are cached and only recomputed when a file's size or
+// modification time changes.
+func ETags(fsys fs.FS, h http.Handler) http.Handler {
+	var cache sync.Map // Name to *etagEntry.
+
+	etag := func(name string) (string, bool) {
+		fi, err := fs.Stat(fsys, name)
+		if err != nil || !fi.Mode().IsRegular() {
+			return "", false
+		}
+		if v, ok := cache.Load(name); ok {
+			e := v.(*etagEntry)
+			if e.size == fi.Size() && e.modTime.Equal(fi.ModTime()) {
+				return e.etag, true
+			}
+		}
+
+		f, err := fsys.Open(name)
+		if err != nil {
+			return "", false
+		}
+		defer f.Close()
+		w := whirlpool.New()
+		if _, err := io.Copy(w, f); err != nil {
+			return "", false
+		}
+		e := &etagEntry{size: fi.Size(), modTime: fi.ModTime(), etag: `"` + hex.EncodeToString(w.Sum(nil)) + `"`}
+		cache.Store(name, e)
+		return e.etag, true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			h.ServeHTTP(w, r)
+			return
+		}
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" {
+			name = "."
+		}
+		tag, ok := etag(name)
+		if !ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("ETag", tag)
+		if noneMatch(r.Header.Get("If-None-Match"), tag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// noneMatch reports whether an If-None-Match header value matches etag,
+// using the weak comparison RFC 9110 prescribes for If-None-Match.
+func noneMatch(header, etag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
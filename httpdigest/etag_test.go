@@ -0,0 +1,73 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpdigest_test
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/tdx/whirlpool"
+	"github.com/tdx/whirlpool/httpdigest"
+)
+
+func TestETags(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":   {Data: []byte("<h1>hello</h1>"), ModTime: time.Unix(1, 0)},
+		"static/a.css": {Data: []byte("body{}"), ModTime: time.Unix(1, 0)},
+	}
+	srv := httptest.NewServer(httpdigest.ETags(fsys, http.FileServerFS(fsys)))
+	defer srv.Close()
+
+	get := func(path, inm string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest("GET", srv.URL+path, nil)
+		if inm != "" {
+			req.Header.Set("If-None-Match", inm)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	etagOf := func(data []byte) string {
+		h := whirlpool.New()
+		h.Write(data)
+		return `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+	}
+
+	want := etagOf(fsys["static/a.css"].Data)
+	resp := get("/static/a.css", "")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != want {
+		t.Fatalf("got %d ETag %s want 200 ETag %s", resp.StatusCode, resp.Header.Get("ETag"), want)
+	}
+	for _, inm := range []string{want, `"other", W/` + want, "*"} {
+		if resp := get("/static/a.css", inm); resp.StatusCode != http.StatusNotModified {
+			t.Fatalf("If-None-Match %s: got %d want 304", inm, resp.StatusCode)
+		}
+	}
+	if resp := get("/static/a.css", `"other"`); resp.StatusCode != http.StatusOK {
+		t.Fatalf("non-matching If-None-Match: got %d want 200", resp.StatusCode)
+	}
+
+	// A changed file gets a new ETag.
+	fsys["static/a.css"] = &fstest.MapFile{Data: []byte("body{color:red}"), ModTime: time.Unix(2, 0)}
+	if resp := get("/static/a.css", want); resp.StatusCode != http.StatusOK ||
+		resp.Header.Get("ETag") != etagOf(fsys["static/a.css"].Data) {
+		t.Fatalf("changed file: got %d ETag %s", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+
+	// Directories and missing files are passed through without an ETag.
+	for _, path := range []string{"/static/", "/missing"} {
+		if resp := get(path, ""); resp.Header.Get("ETag") != "" {
+			t.Fatalf("%s: unexpected ETag %s", path, resp.Header.Get("ETag"))
+		}
+	}
+}
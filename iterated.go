@@ -0,0 +1,34 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import "encoding/binary"
+
+// SumIterated returns the whirlpool checksum of data hashed n times, that
+// is Sum512 applied n times to its own output, as some legacy password
+// stores do. Every iteration after the first hashes exactly one block of
+// digest and one constant padding block, so it compresses the two blocks
+// directly instead of going through a hash. It panics if n < 1.
+func SumIterated(data []byte, n int) Digest {
+	if n < 1 {
+		panic("whirlpool: iteration count must be positive")
+	}
+	d := Sum512(data)
+
+	// The padding of a 512-bit message: a 1-bit, then the length.
+	var pad [wblockBytes]byte
+	pad[0] = 0x80
+	binary.BigEndian.PutUint16(pad[wblockBytes-2:], digestBytes*8)
+
+	for ; n > 1; n-- {
+		var h [8]uint64
+		whirlpoolTables.compress(&h, (*[wblockBytes]byte)(d[:]))
+		whirlpoolTables.compress(&h, &pad)
+		for i, v := range h {
+			binary.BigEndian.PutUint64(d[8*i:], v)
+		}
+	}
+	return d
+}
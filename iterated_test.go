@@ -0,0 +1,33 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestSumIterated(t *testing.T) {
+	data := []byte("password")
+	want := whirlpool.Sum512(data)
+	for n := 1; n <= 5; n++ {
+		if got := whirlpool.SumIterated(data, n); got != want {
+			t.Fatalf("n = %d: got %s want %s", n, got, want)
+		}
+		want = whirlpool.Sum512(want[:])
+	}
+
+	if n := testing.AllocsPerRun(10, func() { whirlpool.SumIterated(data, 100) }); n > 0 {
+		t.Fatalf("got %v allocs want 0", n)
+	}
+}
+
+func BenchmarkSumIterated(b *testing.B) {
+	data := []byte("password")
+	for b.Loop() {
+		whirlpool.SumIterated(data, 1000)
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// SumJSON returns the whirlpool checksum of the canonical form of the JSON
+// document data, as defined by CanonicalJSON. Documents that differ only in
+// whitespace, member order or number and string spelling hash the same.
+func SumJSON(data []byte) (Digest, error) {
+	c, err := CanonicalJSON(data)
+	if err != nil {
+		return Digest{}, err
+	}
+	return Sum512(c), nil
+}
+
+// CanonicalJSON returns the RFC 8785 JSON Canonicalization Scheme (JCS) form
+// of the JSON document data: no insignificant whitespace, object members
+// sorted by their UTF-16 encoded names, numbers serialized like ECMAScript's
+// Number.prototype.toString and strings using the minimal escaping.
+//
+// As RFC 8785 requires I-JSON input, documents that are not valid UTF-8 or
+// that repeat a member name within an object are rejected rather than
+// canonicalized to the same form as a different document.
+func CanonicalJSON(data []byte) ([]byte, error) {
+	if !utf8.Valid(data) {
+		return nil, errors.New("whirlpool: JSON document is not valid UTF-8")
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	v, err := decodeJSON(d, 0)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return nil, errors.New("whirlpool: trailing data after JSON value")
+	}
+
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// maxJSONDepth bounds the nesting of documents accepted by CanonicalJSON,
+// like the limit of encoding/json.
+const maxJSONDepth = 10000
+
+// decodeJSON reads the next value from d token by token, so that duplicate
+// member names are seen instead of silently overwriting each other.
+func decodeJSON(d *json.Decoder, depth int) (interface{}, error) {
+	if depth > maxJSONDepth {
+		return nil, errors.New("whirlpool: JSON document nested too deeply")
+	}
+	tok, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('['):
+		a := []interface{}{}
+		for d.More() {
+			v, err := decodeJSON(d, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		_, err := d.Token() // ']'
+		return a, err
+	case json.Delim('{'):
+		m := make(map[string]interface{})
+		for d.More() {
+			tok, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			k := tok.(string)
+			if _, dup := m[k]; dup {
+				return nil, fmt.Errorf("whirlpool: duplicate JSON member name %q", k)
+			}
+			if m[k], err = decodeJSON(d, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		_, err := d.Token() // '}'
+		return m, err
+	}
+	return tok, nil
+}
+
+func writeCanonical(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return fmt.Errorf("whirlpool: JSON number %s out of range", v)
+		}
+		buf.WriteString(formatES6(f))
+	case string:
+		writeCanonicalString(buf, v)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) })
+
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, k)
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, v[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	}
+	return nil
+}
+
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	const hexDigits = "0123456789abcdef"
+
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			if r < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[r>>4])
+				buf.WriteByte(hexDigits[r&0xf])
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// lessUTF16 orders strings by their UTF-16 code units, as JCS requires.
+func lessUTF16(a, b string) bool {
+	ua := utf16.Encode([]rune(a))
+	ub := utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+// formatES6 formats f like ECMAScript's Number.prototype.toString.
+func formatES6(f float64) string {
+	if f == 0 {
+		return "0" // Also for -0.
+	}
+	sign := ""
+	if f < 0 {
+		sign = "-"
+		f = math.Abs(f)
+	}
+
+	// Shortest round-tripping digits, f = 0.digits × 10^n.
+	e := strconv.FormatFloat(f, 'e', -1, 64)
+	mant, exp := e[:strings.IndexByte(e, 'e')], e[strings.IndexByte(e, 'e')+1:]
+	digits := strings.Replace(mant, ".", "", 1)
+	x, _ := strconv.Atoi(exp)
+	n := x + 1
+	k := len(digits)
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits
+	}
+
+	es := "+"
+	if n-1 < 0 {
+		es = "-"
+	}
+	ev := strconv.Itoa(abs(n - 1))
+	if k == 1 {
+		return sign + digits + "e" + es + ev
+	}
+	return sign + digits[:1] + "." + digits[1:] + "e" + es + ev
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
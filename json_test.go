@@ -0,0 +1,97 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+// Examples from RFC 8785.
+var canonicalJSONTests = []struct {
+	in, out string
+}{
+	{
+		`{
+  "numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`,
+		`{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+	},
+	{
+		`{"€": "Euro Sign", "\r": "Carriage Return", "דּ": "Hebrew Letter Dalet With Dagesh",
+  "1": "One", "😀": "Emoji: Grinning Face", "\u0080": "Control", "ö": "Latin Small Letter O With Diaeresis"}`,
+		`{"\r":"Carriage Return","1":"One","` + "\u0080" + `":"Control","ö":"Latin Small Letter O With Diaeresis","€":"Euro Sign","😀":"Emoji: Grinning Face","דּ":"Hebrew Letter Dalet With Dagesh"}`,
+	},
+	{"0", "0"},
+	{"-0", "0"},
+	{"5e-324", "5e-324"},
+	{"-5e-324", "-5e-324"},
+	{"1.7976931348623157e308", "1.7976931348623157e+308"},
+	{"9007199254740992", "9007199254740992"},
+	{"-9007199254740992", "-9007199254740992"},
+	{"295147905179352825856", "295147905179352830000"},
+	{"9.999999999999997e22", "9.999999999999997e+22"},
+	{"1e23", "1e+23"},
+	{"0.000001", "0.000001"},
+	{"9.999999999999997e-7", "9.999999999999997e-7"},
+	{"333333333.3333332", "333333333.3333332"},
+	{` [ "a" , { } , [ ] ] `, `["a",{},[]]`},
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	for _, tt := range canonicalJSONTests {
+		got, err := whirlpool.CanonicalJSON([]byte(tt.in))
+		if err != nil {
+			t.Fatalf("CanonicalJSON(%s): %v", tt.in, err)
+		}
+		if string(got) != tt.out {
+			t.Fatalf("CanonicalJSON(%s) = %s want %s", tt.in, got, tt.out)
+		}
+	}
+}
+
+func TestCanonicalJSONInvalid(t *testing.T) {
+	deep := strings.Repeat("[", 10002) + strings.Repeat("]", 10002)
+	for _, in := range []string{``, `{`, `1e400`, `{} {}`, `[1,]`, deep} {
+		if _, err := whirlpool.CanonicalJSON([]byte(in)); err == nil {
+			t.Fatalf("CanonicalJSON(%.20s) succeeded", in)
+		}
+	}
+}
+
+func TestCanonicalJSONNotIJSON(t *testing.T) {
+	for _, in := range []string{
+		`{"a":1,"a":2}`,
+		`{"a":1,"b":{"c":1,"c":1}}`,
+		`[{"\u0061":1,"a":2}]`,
+		"\"\xff\"",
+		"{\"a\xfe\":1}",
+	} {
+		if c, err := whirlpool.CanonicalJSON([]byte(in)); err == nil {
+			t.Errorf("CanonicalJSON(%q) = %s, want error", in, c)
+		}
+	}
+}
+
+func TestSumJSON(t *testing.T) {
+	a, err := whirlpool.SumJSON([]byte(`{"b": [1.0, "x"], "a": null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := whirlpool.SumJSON([]byte(`{"a":null,"b":[1,"x"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Fatalf("equivalent documents hash differently")
+	}
+	if a != whirlpool.Sum512([]byte(`{"a":null,"b":[1,"x"]}`)) {
+		t.Fatalf("SumJSON does not hash the canonical form")
+	}
+}
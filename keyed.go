@@ -0,0 +1,73 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import "hash"
+
+// keyed computes a secret-prefix MAC.
+type keyed struct {
+	w     whirlpool // State after the key block and the message so far.
+	wiped bool      // Set by Reset.
+}
+
+// NewKeyed returns a new hash.Hash computing the secret-prefix MAC
+// Whirlpool(K || message), where K is key padded with zeros to BlockSize
+// bytes. Keys longer than BlockSize are hashed first. It is for callers
+// that want a keyed digest in a single call; compare MACs with
+// crypto/hmac.Equal or Digest.EqualBytes rather than bytes.Equal.
+//
+// Like every secret-prefix MAC over a Merkle-Damgård hash it is open to
+// length extension: from the MAC of a message anyone can compute the MAC of
+// that message followed by its padding and more data. Only use it for
+// messages of fixed or encoded length; use the hmac subpackage otherwise.
+//
+// Reset zeroizes the key material as well as the message, so a keyed hash
+// authenticates a single message: call NewKeyed again for the next one.
+// Write and Sum panic after Reset.
+func NewKeyed(key []byte) hash.Hash {
+	var k [wblockBytes]byte
+	if len(key) > wblockBytes {
+		d := Sum512(key)
+		copy(k[:], d[:])
+	} else {
+		copy(k[:], key)
+	}
+
+	h := new(keyed)
+	h.w.Write(k[:])
+	clear(k[:])
+	return h
+}
+
+func (h *keyed) Write(p []byte) (int, error) {
+	if h.wiped {
+		panic("whirlpool: keyed hash used after Reset")
+	}
+	return h.w.Write(p)
+}
+
+func (h *keyed) Sum(b []byte) []byte {
+	if h.wiped {
+		panic("whirlpool: keyed hash used after Reset")
+	}
+	w := h.w
+	d := w.checkSum()
+	w.Zeroize()
+	return append(b, d[:]...)
+}
+
+// Reset zeroizes the key and message state of h.
+func (h *keyed) Reset() {
+	h.w.Zeroize()
+	h.wiped = true
+}
+
+func (h *keyed) Size() int {
+	return digestBytes
+}
+
+func (h *keyed) BlockSize() int {
+	return wblockBytes
+}
@@ -0,0 +1,58 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestNewKeyed(t *testing.T) {
+	for _, key := range [][]byte{nil, []byte("key"), seq(0, 64), seq(0, 200)} {
+		k := make([]byte, whirlpool.BlockSize)
+		if len(key) > whirlpool.BlockSize {
+			copy(k, sum(key))
+		} else {
+			copy(k, key)
+		}
+		for _, n := range []int{0, 1, 100, 200} {
+			msg := seq(n, n)
+			h := whirlpool.NewKeyed(key)
+			h.Write(msg)
+			want := sum(append(k, msg...))
+			if got := h.Sum([]byte("x")); !bytes.Equal(got, append([]byte("x"), want...)) {
+				t.Fatalf("key of %d bytes, message of %d: got %x want x%x", len(key), n, got, want)
+			}
+			// Sum does not change the state.
+			if got := h.Sum(nil); !bytes.Equal(got, want) {
+				t.Fatalf("key of %d bytes, message of %d: second Sum = %x want %x", len(key), n, got, want)
+			}
+		}
+	}
+	if bytes.Equal(whirlpool.NewKeyed([]byte("a")).Sum(nil), whirlpool.NewKeyed([]byte("b")).Sum(nil)) {
+		t.Fatalf("different keys give the same MAC")
+	}
+}
+
+func TestNewKeyedReset(t *testing.T) {
+	h := whirlpool.NewKeyed([]byte("key"))
+	h.Write([]byte("message"))
+	h.Reset()
+	for name, f := range map[string]func(){
+		"Write": func() { h.Write([]byte("message")) },
+		"Sum":   func() { h.Sum(nil) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s after Reset did not panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
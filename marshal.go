@@ -0,0 +1,74 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// The state serialization format is the magic string of the variant, the
+// hash state as big-endian integers, the buffer, the bit length and the
+// number of bits on the buffer as a big-endian integer. The position on the
+// buffer follows from the number of bits and is not stored. The magic
+// strings of all variants have the same length.
+const (
+	magic         = "whp\x01"
+	marshaledSize = len(magic) + digestBytes + wblockBytes + lengthBytes + 8
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler, so that hashing a long
+// stream can be checkpointed and resumed later with UnmarshalBinary.
+func (w *whirlpool) MarshalBinary() ([]byte, error) {
+	return w.AppendBinary(make([]byte, 0, marshaledSize))
+}
+
+// AppendBinary implements encoding.BinaryAppender. It appends the state as
+// written by MarshalBinary to b, without allocating if b has room for it.
+func (w *whirlpool) AppendBinary(b []byte) ([]byte, error) {
+	b = append(b, w.variant().magic...)
+	for _, x := range w.hash {
+		b = binary.BigEndian.AppendUint64(b, x)
+	}
+	b = append(b, w.buffer[:]...)
+	b = append(b, w.bitLength[:]...)
+	return binary.BigEndian.AppendUint64(b, uint64(w.bufferBits)), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It restores a state
+// written by MarshalBinary and rejects states that are inconsistent.
+func (w *whirlpool) UnmarshalBinary(b []byte) error {
+	if len(b) < len(magic) || string(b[:len(magic)]) != w.variant().magic {
+		return errors.New("whirlpool: invalid hash state identifier")
+	}
+	if len(b) != marshaledSize {
+		return errors.New("whirlpool: invalid hash state size")
+	}
+
+	s := whirlpool{t: w.t, size: w.size}
+	b = b[len(magic):]
+	for i := range s.hash {
+		s.hash[i] = binary.BigEndian.Uint64(b)
+		b = b[8:]
+	}
+	b = b[copy(s.buffer[:], b):]
+	b = b[copy(s.bitLength[:], b):]
+	bits := binary.BigEndian.Uint64(b)
+
+	// The buffer holds the bits of the bit length modulo the block size, and
+	// no bits beyond them.
+	low := uint64(binary.BigEndian.Uint16(s.bitLength[lengthBytes-2:]))
+	if bits >= wblockBits || bits != low%wblockBits {
+		return errors.New("whirlpool: invalid hash state bit count")
+	}
+	s.bufferBits = int(bits)
+	s.bufferPos = int(bits / 8)
+	if s.buffer[s.bufferPos]&(0xff>>(bits%8)) != 0 {
+		return errors.New("whirlpool: invalid hash state buffer")
+	}
+
+	*w = s
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestMarshalBinary(t *testing.T) {
+	for _, g := range golden {
+		for split := 0; split <= len(g.in); split++ {
+			h := whirlpool.New()
+			h.Write([]byte(g.in[:split]))
+			state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := whirlpool.New()
+			r.Write([]byte("overwritten"))
+			if err := r.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+				t.Fatalf("%q split at %d: %v", g.in, split, err)
+			}
+			r.Write([]byte(g.in[split:]))
+			if s := fmt.Sprintf("%X", r.Sum(nil)); s != g.out {
+				t.Fatalf("%q split at %d: resumed sum = %s want %s", g.in, split, s, g.out)
+			}
+		}
+	}
+}
+
+func TestUnmarshalBinaryInvalid(t *testing.T) {
+	h := whirlpool.New()
+	h.Write([]byte("abc"))
+	state, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+	n := len(state)
+
+	bad := map[string][]byte{
+		"empty":     nil,
+		"magic":     append([]byte("xxxx"), state[4:]...),
+		"truncated": state[:n-1],
+		"long":      append(append([]byte{}, state...), 0),
+		// The bit count must match the bit length.
+		"bit count": append(append([]byte{}, state[:n-1]...), 25),
+		// Bits beyond the bit count must be zero.
+		"buffer": func() []byte {
+			s := append([]byte{}, state...)
+			s[4+64+3] = 1
+			return s
+		}(),
+	}
+	for name, b := range bad {
+		r := whirlpool.New()
+		if err := r.(encoding.BinaryUnmarshaler).UnmarshalBinary(b); err == nil {
+			t.Errorf("%s: invalid state accepted", name)
+		}
+		// A rejected state leaves the hash untouched.
+		if !bytes.Equal(r.Sum(nil), sum(nil)) {
+			t.Errorf("%s: hash modified by rejected state", name)
+		}
+	}
+}
+
+func TestAppendBinary(t *testing.T) {
+	h := whirlpool.New()
+	h.Write([]byte("abc"))
+	state, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+
+	a := h.(encoding.BinaryAppender)
+	prefix := []byte("prefix")
+	got, err := a.AppendBinary(prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, append([]byte("prefix"), state...)) {
+		t.Fatalf("AppendBinary = %x want prefix followed by %x", got, state)
+	}
+
+	buf := make([]byte, 0, len(state))
+	if n := testing.AllocsPerRun(100, func() { a.AppendBinary(buf[:0]) }); n != 0 {
+		t.Errorf("AppendBinary: %v allocs, want 0", n)
+	}
+}
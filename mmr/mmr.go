@@ -0,0 +1,375 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mmr implements a Merkle Mountain Range, an append-only
+// authenticated accumulator, using whirlpool for node hashing.
+//
+// Leaves are hashed as H(0x00 || data) and inner nodes as
+// H(0x01 || left || right). The range is a list of perfect binary trees
+// (mountains), one for every set bit of the leaf count, and its root is
+// H(0x02 || count || peak_1 || ... || peak_k), with the leaf count as a 64-bit
+// big-endian integer and the mountain peaks ordered from left to right.
+//
+// Besides inclusion proofs for single leaves, an MMR can prove that an older
+// root is a prefix of the current range, so replicas can check that history
+// was only appended to and never rewritten.
+package mmr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash"
+
+	"github.com/tdx/whirlpool"
+)
+
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+	rootPrefix = 0x02
+)
+
+// MMR is a Merkle Mountain Range. The zero value is an empty range.
+type MMR struct {
+	// levels[h] holds the roots of all complete subtrees of height h, left
+	// to right; levels[0] are the leaf hashes.
+	levels [][][]byte
+	leaves uint64
+}
+
+// Proof is an inclusion proof of a leaf in a range of a given size.
+type Proof struct {
+	Leaf   uint64   // Index of the leaf.
+	Leaves uint64   // Number of leaves in the range the proof is for.
+	Path   [][]byte // Siblings on the path from the leaf up to its peak.
+	Peaks  [][]byte // The other peaks of the range, left to right.
+}
+
+// ConsistencyProof proves that a range of OldLeaves leaves is a prefix of a
+// range of NewLeaves leaves, that is that the newer range only appended to
+// the older one.
+type ConsistencyProof struct {
+	OldLeaves uint64
+	NewLeaves uint64
+	OldPeaks  [][]byte // Peaks of the older range, left to right.
+	Nodes     [][]byte // Roots of the appended subtrees, in proof order.
+}
+
+// New returns an empty Merkle Mountain Range.
+func New() *MMR {
+	return new(MMR)
+}
+
+// Append adds a leaf and returns its index.
+func (m *MMR) Append(data []byte) uint64 {
+	m.push(0, hashLeaf(data))
+	for h := 0; len(m.levels[h])%2 == 0; h++ {
+		l := m.levels[h]
+		m.push(h+1, hashNode(l[len(l)-2], l[len(l)-1]))
+	}
+	m.leaves++
+	return m.leaves - 1
+}
+
+func (m *MMR) push(h int, node []byte) {
+	if h == len(m.levels) {
+		m.levels = append(m.levels, nil)
+	}
+	m.levels[h] = append(m.levels[h], node)
+}
+
+// Leaves returns the number of leaves in the range.
+func (m *MMR) Leaves() uint64 {
+	return m.leaves
+}
+
+// Root returns the root hash of the range.
+func (m *MMR) Root() []byte {
+	return bag(m.leaves, m.peaks())
+}
+
+// peaks returns the mountain peaks, left to right.
+func (m *MMR) peaks() [][]byte {
+	var peaks [][]byte
+	for h := len(m.levels) - 1; h >= 0; h-- {
+		if m.leaves&(1<<uint(h)) != 0 {
+			peaks = append(peaks, m.levels[h][len(m.levels[h])-1])
+		}
+	}
+	return peaks
+}
+
+// Prove returns an inclusion proof for leaf i.
+func (m *MMR) Prove(i uint64) (*Proof, error) {
+	if i >= m.leaves {
+		return nil, errors.New("mmr: leaf index out of range")
+	}
+	mountain, height := locate(m.leaves, i)
+
+	p := &Proof{Leaf: i, Leaves: m.leaves}
+	for h := 0; h < height; h++ {
+		p.Path = append(p.Path, m.levels[h][(i>>uint(h))^1])
+	}
+	for j, peak := range m.peaks() {
+		if j != mountain {
+			p.Peaks = append(p.Peaks, peak)
+		}
+	}
+	return p, nil
+}
+
+// ErrNodeSize is returned when verifying a proof if the root or one of the
+// proof nodes is not a whirlpool checksum of whirlpool.Size bytes.
+var ErrNodeSize = errors.New("mmr: proof node or root has the wrong length")
+
+// Verify reports whether p proves that data is a leaf of the range with the
+// given root. It returns ErrNodeSize if root or a node of p has the wrong
+// length; other malformed proofs just do not verify.
+func Verify(root, data []byte, p *Proof) (bool, error) {
+	if p == nil || p.Leaf >= p.Leaves {
+		return false, nil
+	}
+	if err := checkNodes([][]byte{root}, p.Path, p.Peaks); err != nil {
+		return false, err
+	}
+	mountain, height := locate(p.Leaves, p.Leaf)
+	if len(p.Path) != height || len(p.Peaks) != popCount(p.Leaves)-1 {
+		return false, nil
+	}
+
+	node := hashLeaf(data)
+	for h, sibling := range p.Path {
+		if (p.Leaf>>uint(h))&1 == 0 {
+			node = hashNode(node, sibling)
+		} else {
+			node = hashNode(sibling, node)
+		}
+	}
+
+	peaks := make([][]byte, 0, len(p.Peaks)+1)
+	peaks = append(peaks, p.Peaks[:mountain]...)
+	peaks = append(peaks, node)
+	peaks = append(peaks, p.Peaks[mountain:]...)
+	return bytes.Equal(bag(p.Leaves, peaks), root), nil
+}
+
+// checkNodes returns ErrNodeSize unless every node has whirlpool.Size bytes.
+func checkNodes(lists ...[][]byte) error {
+	for _, l := range lists {
+		for _, node := range l {
+			if len(node) != whirlpool.Size {
+				return ErrNodeSize
+			}
+		}
+	}
+	return nil
+}
+
+// ProveConsistency returns a proof that the range as it was with old leaves
+// is a prefix of the current range.
+func (m *MMR) ProveConsistency(old uint64) (*ConsistencyProof, error) {
+	if old == 0 || old > m.leaves {
+		return nil, errors.New("mmr: old size out of range")
+	}
+	p := &ConsistencyProof{OldLeaves: old, NewLeaves: m.leaves}
+	for _, pk := range peakPositions(old) {
+		p.OldPeaks = append(p.OldPeaks, m.levels[pk.height][pk.index])
+	}
+	for _, pk := range peakPositions(m.leaves) {
+		m.proveNode(p, old, pk)
+	}
+	return p, nil
+}
+
+// proveNode adds the nodes needed to recompute node pos from the old peaks.
+func (m *MMR) proveNode(p *ConsistencyProof, old uint64, pos position) {
+	switch {
+	case pos.first() >= old:
+		p.Nodes = append(p.Nodes, m.levels[pos.height][pos.index])
+	case pos.isPeakOf(old):
+	default:
+		left, right := pos.children()
+		m.proveNode(p, old, left)
+		m.proveNode(p, old, right)
+	}
+}
+
+// VerifyConsistency reports whether p proves that the range with root
+// newRoot extends the range with root oldRoot. Like Verify, it returns
+// ErrNodeSize if a root or a node of p has the wrong length.
+func VerifyConsistency(oldRoot, newRoot []byte, p *ConsistencyProof) (bool, error) {
+	if p == nil || p.OldLeaves == 0 || p.OldLeaves > p.NewLeaves {
+		return false, nil
+	}
+	if err := checkNodes([][]byte{oldRoot, newRoot}, p.OldPeaks, p.Nodes); err != nil {
+		return false, err
+	}
+	oldPos := peakPositions(p.OldLeaves)
+	if len(p.OldPeaks) != len(oldPos) || !bytes.Equal(bag(p.OldLeaves, p.OldPeaks), oldRoot) {
+		return false, nil
+	}
+
+	nodes := p.Nodes
+	v := consistencyVerifier{
+		oldLeaves: p.OldLeaves,
+		old:       make(map[position][]byte),
+		next: func() ([]byte, error) {
+			if len(nodes) == 0 {
+				return nil, errBadProof
+			}
+			n := nodes[0]
+			nodes = nodes[1:]
+			return n, nil
+		},
+	}
+	for i, pos := range oldPos {
+		v.old[pos] = p.OldPeaks[i]
+	}
+	var peaks [][]byte
+	for _, pos := range peakPositions(p.NewLeaves) {
+		node, err := v.node(pos)
+		if err != nil {
+			return false, nil
+		}
+		peaks = append(peaks, node)
+	}
+	return len(nodes) == 0 && bytes.Equal(bag(p.NewLeaves, peaks), newRoot), nil
+}
+
+var errBadProof = errors.New("mmr: malformed proof")
+
+// consistencyVerifier recomputes the peaks of a newer range from the peaks
+// of an older one and the proof nodes returned by next.
+type consistencyVerifier struct {
+	oldLeaves uint64
+	old       map[position][]byte
+	next      func() ([]byte, error)
+}
+
+// node recomputes node pos, mirroring MMR.proveNode.
+func (v *consistencyVerifier) node(pos position) ([]byte, error) {
+	switch {
+	case pos.first() >= v.oldLeaves:
+		return v.next()
+	case pos.isPeakOf(v.oldLeaves):
+		return v.old[pos], nil
+	default:
+		if pos.height == 0 {
+			return nil, errBadProof
+		}
+		lpos, rpos := pos.children()
+		left, err := v.node(lpos)
+		if err != nil {
+			return nil, err
+		}
+		right, err := v.node(rpos)
+		if err != nil {
+			return nil, err
+		}
+		return hashNode(left, right), nil
+	}
+}
+
+// position identifies the subtree of the given height holding leaves
+// [index << height, (index+1) << height).
+type position struct {
+	height int
+	index  uint64
+}
+
+func (p position) first() uint64 {
+	return p.index << uint(p.height)
+}
+
+func (p position) children() (left, right position) {
+	return position{p.height - 1, 2 * p.index}, position{p.height - 1, 2*p.index + 1}
+}
+
+// isPeakOf reports whether p is a mountain peak of a range of n leaves.
+func (p position) isPeakOf(n uint64) bool {
+	for _, pk := range peakPositions(n) {
+		if pk == p {
+			return true
+		}
+	}
+	return false
+}
+
+// peakPositions returns the positions of the peaks of a range of n leaves,
+// left to right.
+func peakPositions(n uint64) []position {
+	var (
+		peaks []position
+		start uint64
+	)
+	for h := 63; h >= 0; h-- {
+		if n&(1<<uint(h)) != 0 {
+			peaks = append(peaks, position{h, start >> uint(h)})
+			start += 1 << uint(h)
+		}
+	}
+	return peaks
+}
+
+// locate returns the index, counted from the left, and the height of the
+// mountain that holds leaf i in a range of n leaves.
+func locate(n, i uint64) (mountain, height int) {
+	var start uint64
+	for h := 63; h >= 0; h-- {
+		size := uint64(1) << uint(h)
+		if n&size == 0 {
+			continue
+		}
+		if i < start+size {
+			return mountain, h
+		}
+		start += size
+		mountain++
+	}
+	panic("mmr: leaf index out of range")
+}
+
+func popCount(n uint64) int {
+	c := 0
+	for ; n != 0; n &= n - 1 {
+		c++
+	}
+	return c
+}
+
+func hashLeaf(data []byte) []byte {
+	w := whirlpool.New()
+	w.Write([]byte{leafPrefix})
+	w.Write(data)
+	return w.Sum(nil)
+}
+
+func hashNode(left, right []byte) []byte {
+	w := whirlpool.New()
+	w.Write([]byte{nodePrefix})
+	w.Write(left)
+	w.Write(right)
+	return w.Sum(nil)
+}
+
+func bag(n uint64, peaks [][]byte) []byte {
+	b := newBagger(n)
+	for _, p := range peaks {
+		b.Write(p)
+	}
+	return b.Sum(nil)
+}
+
+// newBagger returns a hash ready to receive the peaks of a range of n leaves,
+// so the root can be computed without holding all the peaks.
+func newBagger(n uint64) hash.Hash {
+	var c [8]byte
+	binary.BigEndian.PutUint64(c[:], n)
+
+	w := whirlpool.New()
+	w.Write([]byte{rootPrefix})
+	w.Write(c[:])
+	return w
+}
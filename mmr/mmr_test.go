@@ -0,0 +1,191 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mmr_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+	"github.com/tdx/whirlpool/mmr"
+)
+
+func leaf(i int) []byte {
+	return []byte(fmt.Sprintf("leaf %d", i))
+}
+
+func TestProofs(t *testing.T) {
+	m := mmr.New()
+	var roots [][]byte
+	for n := 1; n <= 33; n++ {
+		if i := m.Append(leaf(n - 1)); i != uint64(n-1) {
+			t.Fatalf("Append returned index %d want %d", i, n-1)
+		}
+		root := m.Root()
+		for _, r := range roots {
+			if bytes.Equal(r, root) {
+				t.Fatalf("root repeated after %d leaves", n)
+			}
+		}
+		roots = append(roots, root)
+
+		for i := 0; i < n; i++ {
+			p, err := m.Prove(uint64(i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := mmr.Verify(root, leaf(i), p); !ok || err != nil {
+				t.Fatalf("n=%d: proof for leaf %d does not verify", n, i)
+			}
+			if ok, _ := mmr.Verify(root, leaf(i+1), p); ok {
+				t.Fatalf("n=%d: proof for leaf %d verifies wrong data", n, i)
+			}
+		}
+	}
+}
+
+func TestProofTampering(t *testing.T) {
+	m := mmr.New()
+	for i := 0; i < 11; i++ {
+		m.Append(leaf(i))
+	}
+	root := m.Root()
+	p, _ := m.Prove(5)
+
+	bad := *p
+	bad.Leaf = 4
+	if ok, _ := mmr.Verify(root, leaf(5), &bad); ok {
+		t.Fatalf("proof verified with the wrong index")
+	}
+	bad = *p
+	bad.Leaves = 12
+	if ok, _ := mmr.Verify(root, leaf(5), &bad); ok {
+		t.Fatalf("proof verified with the wrong size")
+	}
+	bad = *p
+	bad.Path = append([][]byte{}, p.Path...)
+	bad.Path[0] = bytes.Repeat([]byte{0}, 64)
+	if ok, _ := mmr.Verify(root, leaf(5), &bad); ok {
+		t.Fatalf("proof verified with a tampered path")
+	}
+	if ok, _ := mmr.Verify(root, leaf(5), nil); ok {
+		t.Fatalf("nil proof verified")
+	}
+	if _, err := m.Prove(11); err == nil {
+		t.Fatalf("proof for a missing leaf")
+	}
+}
+
+func TestProofNodeSize(t *testing.T) {
+	m := mmr.New()
+	for i := 0; i < 11; i++ {
+		m.Append(leaf(i))
+	}
+	root := m.Root()
+	p, _ := m.Prove(5)
+
+	bad := *p
+	bad.Path = append([][]byte{}, p.Path...)
+	bad.Path[0] = bad.Path[0][:whirlpool.Size-1]
+	if ok, err := mmr.Verify(root, leaf(5), &bad); ok || err != mmr.ErrNodeSize {
+		t.Fatalf("short path node: Verify = %v, %v want false, ErrNodeSize", ok, err)
+	}
+	bad = *p
+	bad.Peaks = append([][]byte{}, p.Peaks...)
+	bad.Peaks[0] = append(bad.Peaks[0][:whirlpool.Size:whirlpool.Size], 0)
+	if ok, err := mmr.Verify(root, leaf(5), &bad); ok || err != mmr.ErrNodeSize {
+		t.Fatalf("long peak: Verify = %v, %v want false, ErrNodeSize", ok, err)
+	}
+	if ok, err := mmr.Verify(root[:32], leaf(5), p); ok || err != mmr.ErrNodeSize {
+		t.Fatalf("short root: Verify = %v, %v want false, ErrNodeSize", ok, err)
+	}
+}
+
+func TestConsistencyProofs(t *testing.T) {
+	m := mmr.New()
+	var roots [][]byte
+	for n := 1; n <= 33; n++ {
+		m.Append(leaf(n - 1))
+		roots = append(roots, m.Root())
+		for old := 1; old <= n; old++ {
+			p, err := m.ProveConsistency(uint64(old))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := mmr.VerifyConsistency(roots[old-1], m.Root(), p); !ok || err != nil {
+				t.Fatalf("consistency proof %d -> %d does not verify", old, n)
+			}
+			if old > 1 {
+				if ok, _ := mmr.VerifyConsistency(roots[old-2], m.Root(), p); ok {
+					t.Fatalf("consistency proof %d -> %d verifies the wrong old root", old, n)
+				}
+			}
+		}
+	}
+}
+
+func TestConsistencyRewrittenHistory(t *testing.T) {
+	a, b := mmr.New(), mmr.New()
+	for i := 0; i < 7; i++ {
+		a.Append(leaf(i))
+		if i == 2 {
+			b.Append([]byte("rewritten"))
+		} else {
+			b.Append(leaf(i))
+		}
+	}
+	oldRoot := a.Root()
+	for i := 7; i < 12; i++ {
+		a.Append(leaf(i))
+		b.Append(leaf(i))
+	}
+
+	p, _ := b.ProveConsistency(7)
+	if ok, _ := mmr.VerifyConsistency(oldRoot, b.Root(), p); ok {
+		t.Fatalf("rewritten history verified as consistent")
+	}
+	p, _ = a.ProveConsistency(7)
+	bad := *p
+	bad.Nodes = bad.Nodes[:len(bad.Nodes)-1]
+	if ok, _ := mmr.VerifyConsistency(oldRoot, a.Root(), &bad); ok {
+		t.Fatalf("truncated proof verified")
+	}
+	bad.Nodes = append(append([][]byte{}, p.Nodes...), p.Nodes[0])
+	if ok, _ := mmr.VerifyConsistency(oldRoot, a.Root(), &bad); ok {
+		t.Fatalf("proof with extra nodes verified")
+	}
+	if _, err := a.ProveConsistency(13); err == nil {
+		t.Fatalf("proof for a future size")
+	}
+}
+
+func TestConsistencyNodeSize(t *testing.T) {
+	m := mmr.New()
+	for i := 0; i < 7; i++ {
+		m.Append(leaf(i))
+	}
+	oldRoot := m.Root()
+	for i := 7; i < 12; i++ {
+		m.Append(leaf(i))
+	}
+	p, _ := m.ProveConsistency(7)
+
+	bad := *p
+	bad.OldPeaks = append([][]byte{}, p.OldPeaks...)
+	bad.OldPeaks[0] = bad.OldPeaks[0][:whirlpool.Size-1]
+	if ok, err := mmr.VerifyConsistency(oldRoot, m.Root(), &bad); ok || err != mmr.ErrNodeSize {
+		t.Fatalf("short old peak: VerifyConsistency = %v, %v want false, ErrNodeSize", ok, err)
+	}
+	bad = *p
+	bad.Nodes = append([][]byte{}, p.Nodes...)
+	bad.Nodes[0] = nil
+	if ok, err := mmr.VerifyConsistency(oldRoot, m.Root(), &bad); ok || err != mmr.ErrNodeSize {
+		t.Fatalf("empty node: VerifyConsistency = %v, %v want false, ErrNodeSize", ok, err)
+	}
+	if ok, err := mmr.VerifyConsistency(oldRoot, m.Root()[:32], p); ok || err != mmr.ErrNodeSize {
+		t.Fatalf("short new root: VerifyConsistency = %v, %v want false, ErrNodeSize", ok, err)
+	}
+}
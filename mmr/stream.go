@@ -0,0 +1,159 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mmr
+
+import (
+	"bytes"
+	"io"
+)
+
+// nodeSize is the size of a proof node in bytes.
+const nodeSize = 64
+
+// WriteTo writes the nodes of p, Path followed by Peaks, to w as consecutive
+// 64-byte hashes, the format read by VerifyReader.
+func (p *Proof) WriteTo(w io.Writer) (int64, error) {
+	return writeNodes(w, p.Path, p.Peaks)
+}
+
+// WriteTo writes the nodes of p, OldPeaks followed by Nodes, to w as
+// consecutive 64-byte hashes, the format read by VerifyConsistencyReader.
+func (p *ConsistencyProof) WriteTo(w io.Writer) (int64, error) {
+	return writeNodes(w, p.OldPeaks, p.Nodes)
+}
+
+func writeNodes(w io.Writer, lists ...[][]byte) (int64, error) {
+	var n int64
+	for _, l := range lists {
+		for _, node := range l {
+			m, err := w.Write(node)
+			n += int64(m)
+			if err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// VerifyReader is like Verify for a proof of leaf in a range of the given
+// number of leaves whose nodes are read from r, as written by Proof.WriteTo.
+// It holds a constant number of nodes in memory regardless of the size of
+// the range. The error is only set if reading from r fails; a truncated or
+// overlong proof does not verify.
+func VerifyReader(root, data []byte, leaf, leaves uint64, r io.Reader) (bool, error) {
+	if leaf >= leaves {
+		return false, nil
+	}
+	mountain, height := locate(leaves, leaf)
+	nr := nodeReader{r: r}
+
+	node := hashLeaf(data)
+	for h := 0; h < height; h++ {
+		sibling, err := nr.next()
+		if err != nil {
+			return false, ioErr(err)
+		}
+		if (leaf>>uint(h))&1 == 0 {
+			node = hashNode(node, sibling)
+		} else {
+			node = hashNode(sibling, node)
+		}
+	}
+
+	b := newBagger(leaves)
+	for j := 0; j < popCount(leaves); j++ {
+		if j == mountain {
+			b.Write(node)
+			continue
+		}
+		peak, err := nr.next()
+		if err != nil {
+			return false, ioErr(err)
+		}
+		b.Write(peak)
+	}
+	if err := nr.end(); err != nil {
+		return false, ioErr(err)
+	}
+	return bytes.Equal(b.Sum(nil), root), nil
+}
+
+// VerifyConsistencyReader is like VerifyConsistency for a proof between
+// ranges of oldLeaves and newLeaves leaves whose nodes are read from r, as
+// written by ConsistencyProof.WriteTo. It holds O(log n) nodes in memory.
+// The error is only set if reading from r fails; a truncated or overlong
+// proof does not verify.
+func VerifyConsistencyReader(oldRoot, newRoot []byte, oldLeaves, newLeaves uint64, r io.Reader) (bool, error) {
+	if oldLeaves == 0 || oldLeaves > newLeaves {
+		return false, nil
+	}
+	nr := nodeReader{r: r}
+
+	v := consistencyVerifier{
+		oldLeaves: oldLeaves,
+		old:       make(map[position][]byte),
+		next:      nr.next,
+	}
+	ob := newBagger(oldLeaves)
+	for _, pos := range peakPositions(oldLeaves) {
+		peak, err := nr.next()
+		if err != nil {
+			return false, ioErr(err)
+		}
+		v.old[pos] = peak
+		ob.Write(peak)
+	}
+	if !bytes.Equal(ob.Sum(nil), oldRoot) {
+		return false, nil
+	}
+
+	nb := newBagger(newLeaves)
+	for _, pos := range peakPositions(newLeaves) {
+		peak, err := v.node(pos)
+		if err != nil {
+			return false, ioErr(err)
+		}
+		nb.Write(peak)
+	}
+	if err := nr.end(); err != nil {
+		return false, ioErr(err)
+	}
+	return bytes.Equal(nb.Sum(nil), newRoot), nil
+}
+
+// nodeReader reads 64-byte proof nodes.
+type nodeReader struct {
+	r io.Reader
+}
+
+func (nr nodeReader) next() ([]byte, error) {
+	node := make([]byte, nodeSize)
+	if _, err := io.ReadFull(nr.r, node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// end checks that the proof has no trailing data.
+func (nr nodeReader) end() error {
+	var b [1]byte
+	n, err := io.ReadFull(nr.r, b[:])
+	if n > 0 {
+		return errBadProof
+	}
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// ioErr filters out errors that just mean the proof is malformed.
+func ioErr(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF || err == errBadProof {
+		return nil
+	}
+	return err
+}
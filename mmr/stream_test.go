@@ -0,0 +1,82 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mmr_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/iotest"
+
+	"github.com/tdx/whirlpool/mmr"
+)
+
+func TestVerifyReader(t *testing.T) {
+	m := mmr.New()
+	for n := 1; n <= 20; n++ {
+		m.Append(leaf(n - 1))
+		root := m.Root()
+		for i := 0; i < n; i++ {
+			p, _ := m.Prove(uint64(i))
+			var buf bytes.Buffer
+			p.WriteTo(&buf)
+			proof := buf.Bytes()
+
+			ok, err := mmr.VerifyReader(root, leaf(i), uint64(i), uint64(n), bytes.NewReader(proof))
+			if err != nil || !ok {
+				t.Fatalf("n=%d leaf %d: streamed proof does not verify: %v", n, i, err)
+			}
+			if ok, _ := mmr.VerifyReader(root, leaf(i+1), uint64(i), uint64(n), bytes.NewReader(proof)); ok {
+				t.Fatalf("n=%d leaf %d: streamed proof verifies wrong data", n, i)
+			}
+			if len(proof) > 0 {
+				if ok, err := mmr.VerifyReader(root, leaf(i), uint64(i), uint64(n), bytes.NewReader(proof[:len(proof)-1])); ok || err != nil {
+					t.Fatalf("n=%d leaf %d: truncated proof: ok=%v err=%v", n, i, ok, err)
+				}
+			}
+			if ok, _ := mmr.VerifyReader(root, leaf(i), uint64(i), uint64(n), bytes.NewReader(append(proof, 0))); ok {
+				t.Fatalf("n=%d leaf %d: overlong proof verifies", n, i)
+			}
+		}
+	}
+}
+
+func TestVerifyConsistencyReader(t *testing.T) {
+	m := mmr.New()
+	var roots [][]byte
+	for n := 1; n <= 20; n++ {
+		m.Append(leaf(n - 1))
+		roots = append(roots, m.Root())
+		for old := 1; old <= n; old++ {
+			p, _ := m.ProveConsistency(uint64(old))
+			var buf bytes.Buffer
+			p.WriteTo(&buf)
+			proof := buf.Bytes()
+
+			ok, err := mmr.VerifyConsistencyReader(roots[old-1], m.Root(), uint64(old), uint64(n), bytes.NewReader(proof))
+			if err != nil || !ok {
+				t.Fatalf("%d -> %d: streamed proof does not verify: %v", old, n, err)
+			}
+			if ok, _ := mmr.VerifyConsistencyReader(roots[old-1], m.Root(), uint64(old), uint64(n), bytes.NewReader(append(proof, 0))); ok {
+				t.Fatalf("%d -> %d: overlong proof verifies", old, n)
+			}
+			if ok, _ := mmr.VerifyConsistencyReader(roots[old-1], m.Root(), uint64(old), uint64(n), bytes.NewReader(proof[:len(proof)-1])); ok {
+				t.Fatalf("%d -> %d: truncated proof verifies", old, n)
+			}
+		}
+	}
+}
+
+func TestVerifyReaderError(t *testing.T) {
+	m := mmr.New()
+	for i := 0; i < 4; i++ {
+		m.Append(leaf(i))
+	}
+	boom := errors.New("boom")
+	ok, err := mmr.VerifyReader(m.Root(), leaf(0), 0, 4, iotest.ErrReader(boom))
+	if ok || !errors.Is(err, boom) {
+		t.Fatalf("got ok=%v err=%v want read error", ok, err)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// multiAlgorithms are the algorithms a MultiSignature can hold, by BSD tag.
+var multiAlgorithms = map[string]func() hash.Hash{
+	"WHIRLPOOL": New,
+	"SHA256":    sha256.New,
+	"SHA512":    sha512.New,
+}
+
+// MultiSignature is an entry of a checksum file that lists a file with
+// digests of several algorithms, for example while an archive migrates from
+// SHA-256 to whirlpool or back.
+type MultiSignature struct {
+	Path string
+	Sums map[string][]byte // Digests by algorithm: WHIRLPOOL, SHA256 or SHA512.
+}
+
+// ParseMultiSignatures reads a checksum file like ParseSignatures, but also
+// accepts BSD lines of the form "SHA256 (path) = hash" and "SHA512 (path) =
+// hash", and merges all digests of the same path into one MultiSignature.
+// GNU and SFV lines are whirlpool digests. Entries are returned in the
+// order their paths first appear; a path listed twice for the same
+// algorithm is an error.
+func ParseMultiSignatures(r io.Reader) ([]MultiSignature, error) {
+	var sigs []MultiSignature
+	index := make(map[string]int)
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSuffix(sc.Text(), "\r")
+		if isComment(text) {
+			continue
+		}
+		alg, sum, path, err := parseMultiLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		i, ok := index[path]
+		if !ok {
+			i = len(sigs)
+			index[path] = i
+			sigs = append(sigs, MultiSignature{Path: path, Sums: make(map[string][]byte)})
+		}
+		if _, dup := sigs[i].Sums[alg]; dup {
+			return nil, fmt.Errorf("line %d: whirlpool: second %s digest for %s", line, alg, path)
+		}
+		sigs[i].Sums[alg] = sum
+	}
+	return sigs, sc.Err()
+}
+
+// parseMultiLine parses a line of any algorithm in multiAlgorithms.
+func parseMultiLine(text string) (alg string, sum []byte, path string, err error) {
+	tag, rest, ok := strings.Cut(text, " (")
+	alg = strings.ToUpper(tag)
+	newHash := multiAlgorithms[alg]
+	if !ok || newHash == nil || alg == "WHIRLPOOL" {
+		sum, path, err = parseSignatureLine(text)
+		return "WHIRLPOOL", sum, path, err
+	}
+	i := strings.LastIndex(rest, ") = ")
+	if i < 0 {
+		return "", nil, "", fmt.Errorf("whirlpool: malformed BSD line %q", text)
+	}
+	sum, err = hex.DecodeString(rest[i+4:])
+	if err != nil || len(sum) != newHash().Size() {
+		return "", nil, "", fmt.Errorf("whirlpool: malformed %s digest %q", alg, rest[i+4:])
+	}
+	return alg, sum, rest[:i], nil
+}
+
+// VerifyMultiSignatures checks every file listed in sigs against all of its
+// digests, reading each file once. It returns nil if all of them match, or
+// an error wrapping ErrMismatch, naming the file and algorithm, or the I/O
+// error of every entry that does not.
+func VerifyMultiSignatures(fsys fs.FS, sigs []MultiSignature) error {
+	var errs []error
+	for _, s := range sigs {
+		if err := verifyMulti(fsys, s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func verifyMulti(fsys fs.FS, s MultiSignature) error {
+	algs := slices.Sorted(maps.Keys(s.Sums))
+	hashes := make([]hash.Hash, len(algs))
+	writers := make([]io.Writer, len(algs))
+	for i, alg := range algs {
+		newHash := multiAlgorithms[alg]
+		if newHash == nil {
+			return fmt.Errorf("%s: whirlpool: unknown algorithm %s", s.Path, alg)
+		}
+		hashes[i] = newHash()
+		writers[i] = hashes[i]
+	}
+
+	f, err := fsys.Open(strings.TrimPrefix(s.Path, "./"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(io.MultiWriter(writers...), f); err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, alg := range algs {
+		if !bytes.Equal(hashes[i].Sum(nil), s.Sums[alg]) {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", s.Path, alg, ErrMismatch))
+		}
+	}
+	return errors.Join(errs...)
+}
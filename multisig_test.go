@@ -0,0 +1,68 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestMultiSignatures(t *testing.T) {
+	fsys := fstest.MapFS{
+		"both.txt":      {Data: []byte("abc")},
+		"old.txt":       {Data: []byte("a")},
+		"new.txt":       {Data: []byte("abc")},
+		"corrupted.txt": {Data: []byte("not abc")},
+	}
+	sha := func(s string) string { return fmt.Sprintf("%x", sha256.Sum256([]byte(s))) }
+	list := strings.Join([]string{
+		"; migrating to whirlpool",
+		"SHA256 (both.txt) = " + sha("abc"),
+		"WHIRLPOOL (both.txt) = " + golden[3].out,
+		"sha256 (old.txt) = " + sha("a"),
+		golden[3].out + "  new.txt",
+		"SHA256 (corrupted.txt) = " + sha("abc"),
+		golden[3].out + "  corrupted.txt",
+	}, "\n")
+
+	sigs, err := whirlpool.ParseMultiSignatures(strings.NewReader(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs) != 4 || sigs[0].Path != "both.txt" || len(sigs[0].Sums) != 2 || len(sigs[1].Sums) != 1 || sigs[1].Sums["SHA256"] == nil {
+		t.Fatalf("parsed %v", sigs)
+	}
+
+	if err := whirlpool.VerifyMultiSignatures(fsys, sigs[:3]); err != nil {
+		t.Fatalf("matching entries failed: %v", err)
+	}
+	err = whirlpool.VerifyMultiSignatures(fsys, sigs)
+	if !errors.Is(err, whirlpool.ErrMismatch) {
+		t.Fatalf("got %v want ErrMismatch", err)
+	}
+	for _, alg := range []string{"SHA256", "WHIRLPOOL"} {
+		if !strings.Contains(err.Error(), "corrupted.txt: "+alg) {
+			t.Fatalf("%s mismatch not reported: %v", alg, err)
+		}
+	}
+}
+
+func TestMultiSignaturesMalformed(t *testing.T) {
+	for _, list := range []string{
+		"SHA256 (a) = " + golden[3].out,
+		golden[3].out + "  a\nWHIRLPOOL (a) = " + golden[3].out,
+		"SHA256 (a) " + strings.Repeat("0", 64),
+	} {
+		if _, err := whirlpool.ParseMultiSignatures(strings.NewReader(list)); err == nil {
+			t.Errorf("%q accepted", list)
+		}
+	}
+}
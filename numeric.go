@@ -0,0 +1,49 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+// HashInts returns the whirlpool checksum of the integer slice x, identical
+// to HashStruct(x) but without reflection: a list tag and the element count,
+// followed by every element widened to 64 bits, as an int or uint tag and a
+// big-endian integer. Byte slices are excluded, as HashStruct encodes them
+// as byte strings. The result is the same on every architecture, so it
+// can record the provenance of numeric data sets.
+func HashInts[T ~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint16 | ~uint32 | ~uint64](x []T) Digest {
+	var w whirlpool
+	e := encoder{w: &w}
+	if x == nil {
+		e.tag(tagNil)
+		return w.checkSum()
+	}
+	t := byte(tagUint)
+	if ^T(0) < 0 {
+		t = tagInt
+	}
+	e.tagUint64(tagList, uint64(len(x)))
+	for _, v := range x {
+		// Sign-extends signed and zero-extends unsigned values.
+		e.tagUint64(t, uint64(v))
+	}
+	return w.checkSum()
+}
+
+// HashFloats returns the whirlpool checksum of the float slice x, identical
+// to HashStruct(x) but without reflection: a list tag and the element count,
+// followed by every element widened to float64, as a float tag and its IEEE
+// 754 bits in big-endian order. Every NaN is encoded as the same quiet NaN,
+// whatever its sign and payload, while 0 and -0 remain distinct.
+func HashFloats[T ~float32 | ~float64](x []T) Digest {
+	var w whirlpool
+	e := encoder{w: &w}
+	if x == nil {
+		e.tag(tagNil)
+		return w.checkSum()
+	}
+	e.tagUint64(tagList, uint64(len(x)))
+	for _, v := range x {
+		e.float(float64(v))
+	}
+	return w.checkSum()
+}
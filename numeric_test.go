@@ -0,0 +1,64 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestHashInts(t *testing.T) {
+	ints := []int64{0, 1, -1, math.MinInt64, math.MaxInt64}
+	if whirlpool.HashInts(ints) != mustHashStruct(t, ints) {
+		t.Fatalf("HashInts([]int64) differs from HashStruct")
+	}
+	small := []int8{0, 1, -1, -128, 127}
+	if whirlpool.HashInts(small) != mustHashStruct(t, small) {
+		t.Fatalf("HashInts([]int8) differs from HashStruct")
+	}
+	uints := []uint32{0, 1, math.MaxUint32}
+	if whirlpool.HashInts(uints) != mustHashStruct(t, uints) {
+		t.Fatalf("HashInts([]uint32) differs from HashStruct")
+	}
+	if whirlpool.HashInts([]int{}) != mustHashStruct(t, []int{}) ||
+		whirlpool.HashInts([]int(nil)) != mustHashStruct(t, []int(nil)) {
+		t.Fatalf("HashInts of empty or nil slice differs from HashStruct")
+	}
+
+	// The width of the element type does not matter, its signedness does.
+	if whirlpool.HashInts([]int16{-5, 7}) != whirlpool.HashInts([]int64{-5, 7}) {
+		t.Fatalf("int16 and int64 slices with equal values hash differently")
+	}
+	if whirlpool.HashInts([]int{7}) == whirlpool.HashInts([]uint{7}) {
+		t.Fatalf("int and uint slices hash equally")
+	}
+}
+
+func TestHashFloats(t *testing.T) {
+	floats := []float64{0, 1.5, -2, math.Inf(1), math.SmallestNonzeroFloat64}
+	if whirlpool.HashFloats(floats) != mustHashStruct(t, floats) {
+		t.Fatalf("HashFloats([]float64) differs from HashStruct")
+	}
+	if whirlpool.HashFloats([]float32{1.5, -2}) != whirlpool.HashFloats([]float64{1.5, -2}) {
+		t.Fatalf("float32 and float64 slices with equal values hash differently")
+	}
+
+	negNaN := math.Float64frombits(0xfff8000000000000)
+	payloadNaN := math.Float64frombits(0x7ff0000000000001)
+	want := whirlpool.HashFloats([]float64{math.NaN()})
+	for _, nan := range []float64{negNaN, payloadNaN} {
+		if whirlpool.HashFloats([]float64{nan}) != want {
+			t.Fatalf("NaN %x not canonicalized", math.Float64bits(nan))
+		}
+		if mustHashStruct(t, []float64{nan}) != want {
+			t.Fatalf("HashStruct: NaN %x not canonicalized", math.Float64bits(nan))
+		}
+	}
+	if whirlpool.HashFloats([]float64{0}) == whirlpool.HashFloats([]float64{math.Copysign(0, -1)}) {
+		t.Fatalf("0 and -0 hash equally")
+	}
+}
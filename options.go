@@ -0,0 +1,88 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"fmt"
+	"hash"
+)
+
+// Variant selects a revision of the whirlpool design.
+type Variant int
+
+const (
+	// Whirlpool is whirlpool as standardized in ISO/IEC 10118-3:2004.
+	Whirlpool Variant = iota
+
+	// WhirlpoolT is the 2001 revision computed by NewT.
+	WhirlpoolT
+)
+
+// An Option configures the hash returned by NewWith.
+type Option func(*options) error
+
+type options struct {
+	variant Variant
+	size    int
+	rounds  int
+}
+
+// WithVariant selects the variant to compute; the default is Whirlpool.
+func WithVariant(v Variant) Option {
+	return func(o *options) error {
+		if v != Whirlpool && v != WhirlpoolT {
+			return fmt.Errorf("whirlpool: unknown variant %d", v)
+		}
+		o.variant = v
+		return nil
+	}
+}
+
+// WithTruncation truncates the checksum to its first n bytes, like New256
+// and New384 do. n must be between 1 and Size.
+func WithTruncation(n int) Option {
+	return func(o *options) error {
+		if n < 1 || n > Size {
+			return fmt.Errorf("whirlpool: invalid truncation to %d bytes", n)
+		}
+		o.size = n
+		return nil
+	}
+}
+
+// WithRounds computes only the first r rounds, like NewReducedRounds.
+// r must be between 1 and 10.
+func WithRounds(r int) Option {
+	return func(o *options) error {
+		if r < 1 || r > rounds {
+			return fmt.Errorf("whirlpool: invalid number of rounds %d", r)
+		}
+		o.rounds = r
+		return nil
+	}
+}
+
+// NewWith returns a new hash.Hash configured by opts, so that every
+// combination of variant, truncation and number of rounds is available
+// without a constructor for each. Without options it is equivalent to New.
+// It returns an error if an option is invalid.
+func NewWith(opts ...Option) (hash.Hash, error) {
+	o := options{rounds: rounds}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	t := &whirlpoolTables
+	if o.variant == WhirlpoolT {
+		t = tTables()
+	}
+	w := &whirlpool{t: reducedTables(t, o.rounds)}
+	if o.size != Size {
+		w.size = o.size
+	}
+	return w, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/hex"
+	"hash"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestNewWith(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []whirlpool.Option
+		want hash.Hash
+	}{
+		{"default", nil, whirlpool.New()},
+		{"variant", []whirlpool.Option{whirlpool.WithVariant(whirlpool.WhirlpoolT)}, whirlpool.NewT()},
+		{"truncation", []whirlpool.Option{whirlpool.WithTruncation(whirlpool.Size256)}, whirlpool.New256()},
+		{"full size", []whirlpool.Option{whirlpool.WithTruncation(whirlpool.Size)}, whirlpool.New()},
+		{"rounds", []whirlpool.Option{whirlpool.WithRounds(4)}, whirlpool.NewReducedRounds(4)},
+	}
+	for _, tt := range tests {
+		w, err := whirlpool.NewWith(tt.opts...)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for _, h := range []hash.Hash{w, tt.want} {
+			h.Write([]byte("The quick brown fox jumps over the lazy dog"))
+		}
+		if got, want := w.Sum(nil), tt.want.Sum(nil); !bytes.Equal(got, want) {
+			t.Errorf("%s: got %X want %X", tt.name, got, want)
+		}
+		if w.Size() != tt.want.Size() {
+			t.Errorf("%s: Size() = %d want %d", tt.name, w.Size(), tt.want.Size())
+		}
+	}
+}
+
+func TestNewWithCombined(t *testing.T) {
+	w, err := whirlpool.NewWith(
+		whirlpool.WithVariant(whirlpool.WhirlpoolT),
+		whirlpool.WithRounds(10),
+		whirlpool.WithTruncation(20),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("abc"))
+	want, _ := hex.DecodeString(goldenT[1].out)
+	if got, want := w.Sum(nil), want[:20]; !bytes.Equal(got, want) {
+		t.Fatalf("got %X want %X", got, want)
+	}
+
+	// Reduced-round states of different variants are not interchangeable.
+	a, _ := whirlpool.NewWith(whirlpool.WithRounds(4))
+	b, _ := whirlpool.NewWith(whirlpool.WithRounds(4), whirlpool.WithVariant(whirlpool.WhirlpoolT))
+	state, err := a.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err == nil {
+		t.Fatalf("reduced whirlpool state accepted by reduced Whirlpool-T")
+	}
+}
+
+func TestNewWithInvalid(t *testing.T) {
+	for _, opt := range []whirlpool.Option{
+		whirlpool.WithVariant(whirlpool.Variant(7)),
+		whirlpool.WithTruncation(0),
+		whirlpool.WithTruncation(whirlpool.Size + 1),
+		whirlpool.WithRounds(0),
+		whirlpool.WithRounds(11),
+	} {
+		if _, err := whirlpool.NewWith(opt); err == nil {
+			t.Errorf("invalid option accepted")
+		}
+	}
+}
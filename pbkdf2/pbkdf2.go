@@ -0,0 +1,52 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pbkdf2 implements PBKDF2-HMAC-Whirlpool as defined in RFC 8018,
+// the key derivation VeraCrypt and TrueCrypt offer for volume header keys
+// besides their SHA-2 based ones.
+//
+// TrueCrypt derives header keys with 1000 iterations, VeraCrypt with
+// 500000 for volumes and partitions that are not system encryption, both
+// with the 64-byte salt stored in the volume header.
+package pbkdf2
+
+import (
+	"encoding/binary"
+
+	"github.com/tdx/whirlpool"
+	"github.com/tdx/whirlpool/hmac"
+)
+
+// Key derives a key of keyLen bytes from password and salt with iter
+// iterations of HMAC-Whirlpool. It panics if iter or keyLen is less than 1.
+func Key(password, salt []byte, iter, keyLen int) []byte {
+	if iter < 1 || keyLen < 1 {
+		panic("pbkdf2: iteration count and key length must be positive")
+	}
+
+	prf := hmac.New(password)
+	blocks := (keyLen + whirlpool.Size - 1) / whirlpool.Size
+	dk := make([]byte, 0, blocks*whirlpool.Size)
+	var u, t [whirlpool.Size]byte
+	for block := uint32(1); block <= uint32(blocks); block++ {
+		// U_1 = PRF(password, salt || INT(block))
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(binary.BigEndian.AppendUint32(u[:0], block))
+		prf.Sum(u[:0])
+		t = u
+
+		// U_n = PRF(password, U_(n-1)); T = U_1 ^ ... ^ U_iter
+		for n := 2; n <= iter; n++ {
+			prf.Reset()
+			prf.Write(u[:])
+			prf.Sum(u[:0])
+			for i := range t {
+				t[i] ^= u[i]
+			}
+		}
+		dk = append(dk, t[:]...)
+	}
+	return dk[:keyLen]
+}
@@ -0,0 +1,56 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pbkdf2_test
+
+import (
+	"bytes"
+	stdpbkdf2 "crypto/pbkdf2"
+	"fmt"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+	"github.com/tdx/whirlpool/pbkdf2"
+)
+
+// The inputs of RFC 6070 with whirlpool, and the iteration counts of
+// TrueCrypt and VeraCrypt, as computed by OpenSSL.
+var golden = []struct {
+	password, salt string
+	iter, keyLen   int
+	out            string
+}{
+	{"password", "salt", 1, 64, "7E25009BF8AFADE8AB33911D331B5B3E987FC7C3E2D5FDB3F33C183E837C357850A75EB8BAAD2C05B1E3BC7068C2A2D5C0F3E586F401610AD02F525C8FCF2CBD"},
+	{"password", "salt", 2, 64, "110B2E4266F03C334F6085BF421A68D6976A2F767E0BB6041A9C9315EC0D249FC8CB5FAC1F9F3B87DBB98E9B4B220DFE0D6B55F88109DD558C30F0A0356F7D9F"},
+	{"password", "salt", 4096, 64, "4F4C0307915B7E3F948DAAF41EE7805CD2967513A3BE6975A7CCE782402598E6BD950C5051EA0C8185BEBA487B13EB93F5A93B8E2E1E7535643F00DD7C39CAD1"},
+	{"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, 100, "B704488BCC9371A5FA3A7EB6E7555549A96EAE3D572C0D505E1970F8460425D0CCC4CDB091F23082DA6F94D3E594012075443491B608D81AF37952C205403AD336267FF6AE039B0561731909FB35E5722BED8BC7F4805D62CB28239319CE9CB38D055FD2"},
+	// TrueCrypt: 1000 iterations, 192 bytes of header key material.
+	{"password", "salt", 1000, 192, "5AD7361484C7DDE6B23E573C4B61D1FD16023FD6C0170D0B26D70F7AC8C683F06E767804750357B4032297C2AD36CBD84D01476C1298826B71F605DBCDA9E0551157B44F4853587DFEE108124E83C94D257EF279978102D39627675D99DD4E0E2DA6AB8DEF9E091E005F84A5AB675121055E869544342A0BA025640F8B04478EC364606931E7E07A1030737BA81D948D6AC4CD62E608A7F684672B3B29A4E96AC94587C1087EB6620DA431E45AB69257B62EC8036E1BBDD297164E7D7449A11D"},
+	// VeraCrypt: 500000 iterations.
+	{"password", "salt", 500000, 64, "6EDA28F14745C07FFEC946C229A1C9E113560CAC7DC125508CDF3F808E57A258265F4A984F84628F9A29D61829AD9C649DB3B13B29A12DCFD0AB8BE535890838"},
+}
+
+func TestGolden(t *testing.T) {
+	for _, g := range golden {
+		if g.iter > 10000 && testing.Short() {
+			continue
+		}
+		dk := pbkdf2.Key([]byte(g.password), []byte(g.salt), g.iter, g.keyLen)
+		if s := fmt.Sprintf("%X", dk); s != g.out {
+			t.Errorf("Key(%q, %q, %d, %d) = %s want %s", g.password, g.salt, g.iter, g.keyLen, s, g.out)
+		}
+	}
+}
+
+func TestStdlib(t *testing.T) {
+	for _, keyLen := range []int{1, 63, 64, 65, 200} {
+		want, err := stdpbkdf2.Key(whirlpool.New, "secret", []byte("pepper"), 3, keyLen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := pbkdf2.Key([]byte("secret"), []byte("pepper"), 3, keyLen); !bytes.Equal(got, want) {
+			t.Fatalf("keyLen %d: got %x want %x", keyLen, got, want)
+		}
+	}
+}
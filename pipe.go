@@ -0,0 +1,87 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"io"
+	"time"
+)
+
+// PipeStats reports where time went while hashing through SumPipe.
+type PipeStats struct {
+	Bytes int64 // Bytes hashed.
+
+	// ProducerStall is the time the producer spent blocked in Write waiting
+	// for the hasher to take its data. A large value means hashing is the
+	// bottleneck.
+	ProducerStall time.Duration
+
+	// HasherStall is the time the hasher spent waiting for the producer to
+	// write more data. A large value means the producer, usually its I/O, is
+	// the bottleneck.
+	HasherStall time.Duration
+
+	// Hashing is the time spent hashing.
+	Hashing time.Duration
+}
+
+// SumPipe runs produce in its own goroutine, connected to the hasher by a
+// pipe, and returns the whirlpool checksum of everything produce writes
+// together with stall times on both sides of the pipe. If produce returns an
+// error, SumPipe returns that error and no checksum.
+func SumPipe(produce func(w io.Writer) error) (Digest, PipeStats, error) {
+	var (
+		stats PipeStats
+		stall time.Duration // Producer stall, owned by the producer until done.
+		done  = make(chan struct{})
+	)
+	pr, pw := io.Pipe()
+	go func() {
+		defer close(done)
+		err := produce(&stallWriter{w: pw, stall: &stall})
+		pw.CloseWithError(err)
+	}()
+
+	var (
+		w   whirlpool
+		buf = make([]byte, 32*1024)
+		err error
+	)
+	for {
+		start := time.Now()
+		n, rerr := pr.Read(buf)
+		read := time.Now()
+		stats.HasherStall += read.Sub(start)
+
+		w.Write(buf[:n])
+		stats.Hashing += time.Since(read)
+		stats.Bytes += int64(n)
+		if rerr != nil {
+			if rerr != io.EOF {
+				err = rerr
+			}
+			break
+		}
+	}
+	<-done
+	stats.ProducerStall = stall
+	if err != nil {
+		return Digest{}, stats, err
+	}
+	return w.checkSum(), stats, nil
+}
+
+// stallWriter measures how long writes to w block.
+type stallWriter struct {
+	w     io.Writer
+	stall *time.Duration
+}
+
+func (s *stallWriter) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := s.w.Write(p)
+	*s.stall += time.Since(start)
+	return n, err
+}
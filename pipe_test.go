@@ -0,0 +1,64 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestSumPipe(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 10000)
+
+	got, stats, err := whirlpool.SumPipe(func(w io.Writer) error {
+		for p := data; len(p) > 0; p = p[min(len(p), 777):] {
+			if _, err := w.Write(p[:min(len(p), 777)]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != whirlpool.Sum512(data) {
+		t.Fatalf("digest mismatch")
+	}
+	if stats.Bytes != int64(len(data)) {
+		t.Fatalf("Bytes = %d want %d", stats.Bytes, len(data))
+	}
+}
+
+func TestSumPipeSlowProducer(t *testing.T) {
+	_, stats, err := whirlpool.SumPipe(func(w io.Writer) error {
+		for i := 0; i < 3; i++ {
+			time.Sleep(10 * time.Millisecond)
+			w.Write([]byte("x"))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.HasherStall < 20*time.Millisecond {
+		t.Fatalf("HasherStall = %v, want at least 20ms for a slow producer", stats.HasherStall)
+	}
+}
+
+func TestSumPipeError(t *testing.T) {
+	errProduce := errors.New("produce failed")
+	got, _, err := whirlpool.SumPipe(func(w io.Writer) error {
+		w.Write([]byte("partial"))
+		return errProduce
+	})
+	if err != errProduce || got != (whirlpool.Digest{}) {
+		t.Fatalf("got %s, %v want zero Digest, %v", got, err, errProduce)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// SumRanges returns the whirlpool checksums of the given ranges of r, for
+// example the regions of a firmware or disk image that an attestation
+// covers, and a combined digest over all of them. Ranges may overlap and
+// are hashed in the order given.
+//
+// The combined digest is the whirlpool checksum of, for every range in
+// order, its offset and length as 64-bit big-endian integers followed by
+// its checksum, so that it commits to where every range lies as well as to
+// its content. SumRanges returns an error if a range has a negative offset
+// or length or extends beyond the end of r.
+func SumRanges(r io.ReaderAt, ranges []ByteRange) (sums []Digest, combined Digest, err error) {
+	if err := checkRanges(ranges); err != nil {
+		return nil, Digest{}, err
+	}
+	sums = make([]Digest, len(ranges))
+	for i, br := range ranges {
+		var w whirlpool
+		n, err := io.Copy(&w, io.NewSectionReader(r, br.Offset, br.Length))
+		if err != nil {
+			return nil, Digest{}, err
+		}
+		if n < br.Length {
+			return nil, Digest{}, fmt.Errorf("whirlpool: range %d+%d: %w", br.Offset, br.Length, io.ErrUnexpectedEOF)
+		}
+		sums[i] = w.checkSum()
+	}
+	return sums, combineRanges(ranges, sums), nil
+}
+
+// SumRangesStream is SumRanges for a stream that can only be read once from
+// start to end, such as an evidence image piped from a device or over the
+// network. It reads r sequentially, at most up to the end of the last
+// range, and feeds every chunk to all ranges it overlaps, so any number of
+// regions costs a single pass over the data.
+func SumRangesStream(r io.Reader, ranges []ByteRange) (sums []Digest, combined Digest, err error) {
+	if err := checkRanges(ranges); err != nil {
+		return nil, Digest{}, err
+	}
+
+	// Ranges by offset, so that they can be activated in order.
+	order := make([]int, len(ranges))
+	var end int64
+	for i, br := range ranges {
+		order[i] = i
+		end = max(end, br.Offset+br.Length)
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		return cmp.Compare(ranges[a].Offset, ranges[b].Offset)
+	})
+
+	var (
+		hashes = make([]whirlpool, len(ranges))
+		active []int
+		next   int   // Next range of order to activate.
+		pos    int64 // Stream offset of buf[0].
+		buf    = make([]byte, 32*1024)
+	)
+	for pos < end {
+		n, rerr := r.Read(buf[:min(int64(len(buf)), end-pos)])
+		for next < len(order) && ranges[order[next]].Offset < pos+int64(n) {
+			active = append(active, order[next])
+			next++
+		}
+		active = slices.DeleteFunc(active, func(i int) bool {
+			br := ranges[i]
+			lo := max(br.Offset, pos) - pos
+			hi := min(br.Offset+br.Length, pos+int64(n)) - pos
+			if lo < hi {
+				hashes[i].Write(buf[lo:hi])
+			}
+			return br.Offset+br.Length <= pos+int64(n)
+		})
+		pos += int64(n)
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return nil, Digest{}, rerr
+		}
+	}
+	if pos < end {
+		return nil, Digest{}, fmt.Errorf("whirlpool: stream ends at %d before the end of the ranges at %d: %w", pos, end, io.ErrUnexpectedEOF)
+	}
+
+	sums = make([]Digest, len(ranges))
+	for i := range hashes {
+		sums[i] = hashes[i].checkSum()
+	}
+	return sums, combineRanges(ranges, sums), nil
+}
+
+func checkRanges(ranges []ByteRange) error {
+	for _, br := range ranges {
+		if br.Offset < 0 || br.Length < 0 {
+			return fmt.Errorf("whirlpool: invalid range %d+%d", br.Offset, br.Length)
+		}
+	}
+	return nil
+}
+
+// combineRanges returns the combined digest of SumRanges.
+func combineRanges(ranges []ByteRange, sums []Digest) Digest {
+	var all whirlpool
+	for i, br := range ranges {
+		var hdr [16]byte
+		binary.BigEndian.PutUint64(hdr[:8], uint64(br.Offset))
+		binary.BigEndian.PutUint64(hdr[8:], uint64(br.Length))
+		all.Write(hdr[:])
+		all.Write(sums[i][:])
+	}
+	return all.checkSum()
+}
@@ -0,0 +1,116 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestSumRanges(t *testing.T) {
+	image := seq(0, 10000)
+	ranges := []whirlpool.ByteRange{
+		{Offset: 4096, Length: 1024},
+		{Offset: 0, Length: 512},
+		{Offset: 500, Length: 100}, // Overlaps the previous range.
+		{Offset: 10000, Length: 0},
+	}
+	sums, combined, err := whirlpool.SumRanges(bytes.NewReader(image), ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var enc []byte
+	for i, br := range ranges {
+		want := whirlpool.Sum512(image[br.Offset : br.Offset+br.Length])
+		if sums[i] != want {
+			t.Fatalf("range %d: got %s want %s", i, sums[i], want)
+		}
+		enc = binary.BigEndian.AppendUint64(enc, uint64(br.Offset))
+		enc = binary.BigEndian.AppendUint64(enc, uint64(br.Length))
+		enc = append(enc, want[:]...)
+	}
+	if want := whirlpool.Sum512(enc); combined != want {
+		t.Fatalf("combined = %s want %s", combined, want)
+	}
+
+	// The combined digest depends on the position of the ranges.
+	moved := []whirlpool.ByteRange{{Offset: 0, Length: 512}, {Offset: 4096, Length: 1024}}
+	if _, c, _ := whirlpool.SumRanges(bytes.NewReader(image), moved); c == combined {
+		t.Fatalf("reordering ranges kept the combined digest")
+	}
+}
+
+func TestSumRangesInvalid(t *testing.T) {
+	r := bytes.NewReader(seq(0, 100))
+	if _, _, err := whirlpool.SumRanges(r, []whirlpool.ByteRange{{Offset: 50, Length: 51}}); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("got %v want io.ErrUnexpectedEOF", err)
+	}
+	if _, _, err := whirlpool.SumRanges(r, []whirlpool.ByteRange{{Offset: -1, Length: 1}}); err == nil {
+		t.Fatalf("negative offset accepted")
+	}
+}
+
+func TestSumRangesStream(t *testing.T) {
+	image := seq(0, 200000)
+	var ranges []whirlpool.ByteRange
+	for i := int64(0); i < 40; i++ {
+		// Overlapping ranges of varied sizes, given out of order.
+		off := (i * 7919) % 150000
+		ranges = append(ranges, whirlpool.ByteRange{Offset: off, Length: (i * 104729) % 50000})
+	}
+	ranges = append(ranges, whirlpool.ByteRange{Offset: 0, Length: 0}, whirlpool.ByteRange{Offset: 199999, Length: 1})
+
+	want, wantCombined, err := whirlpool.SumRanges(bytes.NewReader(image), ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A reader returning few bytes at a time exercises partial overlaps.
+	r := &countingReader{r: iotest.HalfReader(bytes.NewReader(image))}
+	sums, combined, err := whirlpool.SumRangesStream(r, ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		if sums[i] != want[i] {
+			t.Fatalf("range %d %v: got %s want %s", i, ranges[i], sums[i], want[i])
+		}
+	}
+	if combined != wantCombined {
+		t.Fatalf("combined = %s want %s", combined, wantCombined)
+	}
+	if r.n != int64(len(image)) {
+		t.Fatalf("read %d bytes want %d", r.n, len(image))
+	}
+
+	// Reading stops at the end of the last range.
+	r = &countingReader{r: bytes.NewReader(image)}
+	whirlpool.SumRangesStream(r, ranges[:1])
+	if end := ranges[0].Offset + ranges[0].Length; r.n != end {
+		t.Fatalf("read %d bytes want %d", r.n, end)
+	}
+
+	_, _, err = whirlpool.SumRangesStream(bytes.NewReader(image[:1000]), []whirlpool.ByteRange{{Offset: 900, Length: 101}})
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("got %v want io.ErrUnexpectedEOF", err)
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,32 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import "hash"
+
+// NewReducedRounds returns a new hash.Hash computing whirlpool with only the
+// first r of its 10 rounds. It is meant for cryptanalysis and for fuzzing
+// code built on this package faster, and must not be used where whirlpool's
+// security matters. NewReducedRounds(10) is equivalent to New. It panics if
+// r is not in the range 1 to 10.
+func NewReducedRounds(r int) hash.Hash {
+	if r < 1 || r > rounds {
+		panic("whirlpool: rounds out of range")
+	}
+	return &whirlpool{t: reducedTables(&whirlpoolTables, r)}
+}
+
+// reducedTables returns the tables of t with only r rounds, which must be
+// in range. The magic keeps the variant letter of t and adds the number of
+// rounds.
+func reducedTables(t *tables, r int) *tables {
+	if r == t.rounds {
+		return t
+	}
+	rt := *t
+	rt.rounds = r
+	rt.magic = "r" + t.magic[2:3] + string(rune('0'+r)) + "\x01"
+	return &rt
+}
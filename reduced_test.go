@@ -0,0 +1,63 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"encoding"
+	"fmt"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+var goldenReduced = []struct {
+	rounds int
+	whirlpoolTest
+}{
+	{1, whirlpoolTest{"17ACC0678B3102CC0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000", ""}},
+	{1, whirlpoolTest{"6E56F97F3FF32FE0EBCDCD13CD26DE872D2C98985A98B4C28903838F8F068F0C0000000000000000000000000000000005140528110A2D050000000000000018", "abc"}},
+	{4, whirlpoolTest{"17AE0A491BC1FD7EA5BB3FAFA636C9A515A5904FC41B6BE959CAAD86E89A0DB97BBF5319C862593E676ED61AC4D9D2D1F0F3FEF9E3C6F0FB19BFB3F515248459", ""}},
+	{4, whirlpoolTest{"F4DFBD9ECA0FCA19D3C1CF6CA02E41E874C35C6315C5B98A36F04E42FE2DD05E0A3C5076A191F8EC486BC73E61D2A4DCEDB8F0C52CF05C72FA3D00D4FB9A66E7", "abc"}},
+	{10, whirlpoolTest{"4E2448A4C6F486BB16B6562C73B4020BF3043E3A731BCE721AE1B303D97E6D4C7181EEBDB6C57E277D0E34957114CBD6C797FC9D95D8B582D225292076D4EEF5", "abc"}},
+}
+
+func TestReducedRounds(t *testing.T) {
+	for _, g := range goldenReduced {
+		w := whirlpool.NewReducedRounds(g.rounds)
+		w.Write([]byte(g.in))
+		if s := fmt.Sprintf("%X", w.Sum(nil)); s != g.out {
+			t.Fatalf("%d rounds of %q = %s want %s", g.rounds, g.in, s, g.out)
+		}
+	}
+}
+
+func TestReducedRoundsRange(t *testing.T) {
+	for _, r := range []int{0, 11, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewReducedRounds(%d) did not panic", r)
+				}
+			}()
+			whirlpool.NewReducedRounds(r)
+		}()
+	}
+}
+
+func TestReducedRoundsMarshal(t *testing.T) {
+	state, err := whirlpool.NewReducedRounds(4).(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := whirlpool.New().(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err == nil {
+		t.Fatalf("4-round state accepted by whirlpool")
+	}
+	if err := whirlpool.NewReducedRounds(5).(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err == nil {
+		t.Fatalf("4-round state accepted by 5-round whirlpool")
+	}
+	if err := whirlpool.NewReducedRounds(4).(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+}
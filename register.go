@@ -0,0 +1,32 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import "hash"
+
+// algorithms maps the names under which Register registers the hashes of
+// this package to their constructors.
+var algorithms = map[string]func() hash.Hash{
+	"whirlpool":     New,
+	"whirlpool-256": New256,
+	"whirlpool-384": New384,
+	"whirlpool-t":   NewT,
+}
+
+// Register calls register with the name and constructor of every hash of
+// this package, in no particular order, to add them to a registry that
+// looks hashes up by name at run time. The names are "whirlpool",
+// "whirlpool-256", "whirlpool-384" and "whirlpool-t". For example, with a
+// registry providing Add(name string, fn func() hash.Hash):
+//
+//	whirlpool.Register(hashes.Add)
+//
+// Importing this package registers nothing by itself, so programs choose
+// whether and where whirlpool is discoverable.
+func Register(register func(name string, fn func() hash.Hash)) {
+	for name, fn := range algorithms {
+		register(name, fn)
+	}
+}
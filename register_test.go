@@ -0,0 +1,37 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"fmt"
+	"hash"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestRegister(t *testing.T) {
+	registry := make(map[string]func() hash.Hash)
+	whirlpool.Register(func(name string, fn func() hash.Hash) {
+		if _, dup := registry[name]; dup {
+			t.Fatalf("%s registered twice", name)
+		}
+		registry[name] = fn
+	})
+
+	for _, name := range []string{"whirlpool", "whirlpool-256", "whirlpool-384", "whirlpool-t"} {
+		if registry[name] == nil {
+			t.Fatalf("%s not registered", name)
+		}
+	}
+	w := registry["whirlpool"]()
+	w.Write([]byte(golden[3].in))
+	if s := fmt.Sprintf("%X", w.Sum(nil)); s != golden[3].out {
+		t.Fatalf("whirlpool(%q) = %s want %s", golden[3].in, s, golden[3].out)
+	}
+	if n := registry["whirlpool-256"]().Size(); n != whirlpool.Size256 {
+		t.Fatalf("whirlpool-256 has size %d", n)
+	}
+}
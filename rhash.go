@@ -0,0 +1,106 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SignatureFormat selects the line format of a checksum file, following the
+// formats rhash writes for whirlpool.
+type SignatureFormat int
+
+const (
+	// FormatGNU is the default "hash  path" format of rhash and the *sum tools.
+	FormatGNU SignatureFormat = iota
+	// FormatBSD is the "WHIRLPOOL (path) = hash" format of rhash --bsd.
+	FormatBSD
+	// FormatSFV is the "path hash" format of rhash --sfv.
+	FormatSFV
+)
+
+const bsdTag = "WHIRLPOOL ("
+
+// Signature is a single entry of a checksum file.
+type Signature struct {
+	Path string
+	Sum  []byte
+}
+
+// ParseSignatures reads a checksum file in any of the formats written by
+// rhash for whirlpool: GNU, BSD or SFV lines, which may be mixed, with
+// upper- or lowercase hex digests. Empty lines and comment lines starting
+// with ';' or '#' are skipped.
+func ParseSignatures(r io.Reader) ([]Signature, error) {
+	var sigs []Signature
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSuffix(sc.Text(), "\r")
+		if isComment(text) {
+			continue
+		}
+		sum, path, err := parseSignatureLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		sigs = append(sigs, Signature{Path: path, Sum: sum})
+	}
+	return sigs, sc.Err()
+}
+
+// WriteSignatures writes sigs to w in the given format with lowercase hex
+// digests, as rhash does by default.
+func WriteSignatures(w io.Writer, format SignatureFormat, sigs []Signature) error {
+	bw := bufio.NewWriter(w)
+	for _, s := range sigs {
+		h := hex.EncodeToString(s.Sum)
+		switch format {
+		case FormatBSD:
+			fmt.Fprintf(bw, "%s%s) = %s\n", bsdTag, s.Path, h)
+		case FormatSFV:
+			fmt.Fprintf(bw, "%s %s\n", s.Path, h)
+		default:
+			fmt.Fprintf(bw, "%s  %s\n", h, s.Path)
+		}
+	}
+	return bw.Flush()
+}
+
+func isComment(text string) bool {
+	return text == "" || text[0] == ';' || text[0] == '#'
+}
+
+// parseSignatureLine parses a GNU, BSD or SFV checksum line.
+func parseSignatureLine(text string) (sum []byte, path string, err error) {
+	if len(text) > len(bsdTag) && strings.EqualFold(text[:len(bsdTag)], bsdTag) {
+		i := strings.LastIndex(text, ") = ")
+		if i < len(bsdTag) {
+			return nil, "", fmt.Errorf("whirlpool: malformed BSD line %q", text)
+		}
+		sum, err = decodeDigest(text[i+4:])
+		return sum, text[len(bsdTag):i], err
+	}
+	if sum, path, err := parseListLine(text); err == nil {
+		return sum, path, nil
+	}
+	if i := strings.LastIndexByte(text, ' '); i > 0 {
+		if sum, err := decodeDigest(text[i+1:]); err == nil {
+			return sum, text[:i], nil
+		}
+	}
+	return nil, "", fmt.Errorf("whirlpool: malformed checksum line %q", text)
+}
+
+func decodeDigest(s string) ([]byte, error) {
+	sum, err := hex.DecodeString(s)
+	if err != nil || len(sum) != digestBytes {
+		return nil, fmt.Errorf("whirlpool: malformed digest %q", s)
+	}
+	return sum, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestParseSignatures(t *testing.T) {
+	a, abc := strings.ToLower(golden[1].out), golden[3].out
+	file := strings.Join([]string{
+		"; Generated by RHash v1.4.4 on 2024-01-01 at 10:00.00",
+		"; comment",
+		a + "  a.txt",
+		abc + " *bin/abc",
+		"WHIRLPOOL (dir/with space.txt) = " + a,
+		"whirlpool (x) = y) = " + abc,
+		"sfv name.txt " + abc,
+		"# another comment\r",
+		"",
+	}, "\r\n")
+
+	sigs, err := whirlpool.ParseSignatures(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ path, sum string }{
+		{"a.txt", golden[1].out},
+		{"bin/abc", golden[3].out},
+		{"dir/with space.txt", golden[1].out},
+		{"x) = y", golden[3].out},
+		{"sfv name.txt", golden[3].out},
+	}
+	if len(sigs) != len(want) {
+		t.Fatalf("got %d signatures want %d", len(sigs), len(want))
+	}
+	for i, w := range want {
+		if sigs[i].Path != w.path || fmt.Sprintf("%X", sigs[i].Sum) != w.sum {
+			t.Fatalf("signature %d = %q %X want %q %s", i, sigs[i].Path, sigs[i].Sum, w.path, w.sum)
+		}
+	}
+
+	if _, err := whirlpool.ParseSignatures(strings.NewReader("not a checksum line\n")); err == nil {
+		t.Fatalf("malformed line accepted")
+	}
+}
+
+func TestWriteSignatures(t *testing.T) {
+	sigs := []whirlpool.Signature{
+		{Path: "a.txt", Sum: sum([]byte("a"))},
+		{Path: "some dir/abc", Sum: sum([]byte("abc"))},
+	}
+	a, abc := strings.ToLower(golden[1].out), strings.ToLower(golden[3].out)
+	formats := []struct {
+		format whirlpool.SignatureFormat
+		want   string
+	}{
+		{whirlpool.FormatGNU, a + "  a.txt\n" + abc + "  some dir/abc\n"},
+		{whirlpool.FormatBSD, "WHIRLPOOL (a.txt) = " + a + "\nWHIRLPOOL (some dir/abc) = " + abc + "\n"},
+		{whirlpool.FormatSFV, "a.txt " + a + "\nsome dir/abc " + abc + "\n"},
+	}
+	for _, f := range formats {
+		var buf bytes.Buffer
+		if err := whirlpool.WriteSignatures(&buf, f.format, sigs); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != f.want {
+			t.Fatalf("format %d:\n%s\nwant\n%s", f.format, buf.String(), f.want)
+		}
+		back, err := whirlpool.ParseSignatures(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range sigs {
+			if back[i].Path != sigs[i].Path || !bytes.Equal(back[i].Sum, sigs[i].Sum) {
+				t.Fatalf("format %d: round trip of %v gave %v", f.format, sigs[i], back[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"errors"
+	"hash"
+)
+
+// whirlpoolMatrix is the first row of the diffusion matrix of whirlpool.
+var whirlpoolMatrix = [8]byte{1, 1, 4, 1, 8, 5, 2, 9}
+
+// NewCustomSBox returns a new hash.Hash computing whirlpool with the S-box s
+// in place of the standard one, including the round constants that are
+// derived from it. It is meant for private deployments that already run
+// such a variant and would otherwise maintain a fork of this package.
+//
+// The result is NOT whirlpool: its checksums are not interoperable with any
+// other implementation, and its security rests on s, for which this
+// package checks nothing but that it is a permutation. Marshaled states of
+// all custom S-boxes share one identifier, so resuming a state with a
+// different S-box is not detected. NewCustomSBox returns an error if s is
+// not a permutation of the 256 byte values.
+func NewCustomSBox(s *[256]byte) (hash.Hash, error) {
+	var seen [256]bool
+	for _, v := range s {
+		if seen[v] {
+			return nil, errors.New("whirlpool: S-box is not a permutation")
+		}
+		seen[v] = true
+	}
+	sbox := *s
+	return &whirlpool{t: newTables(&sbox, whirlpoolMatrix, "whx\x01")}, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+// The S-box of whirlpool, row by row.
+const whirlpoolSBox = "" +
+	"1823c6e887b8014f36a6d2f5796f9152" +
+	"60bc9b8ea30c7b351de0d7c22e4bfe57" +
+	"157737e59ff04ada58c9290ab1a06b85" +
+	"bd5d10f4cb3e0567e427418ba77d95d8" +
+	"fbee7c66dd17479eca2dbf07ad5a8333" +
+	"6302aa71c81949d9f2e35b889a2632b0" +
+	"e90fd580becd3448ff7a905f20681aae" +
+	"b454932264f173124008c3ecdba18d3d" +
+	"9700cf2b7682d61bb5af6a5045f330ef" +
+	"3f55a2ea65ba2fc0de1cfd4d9275068a" +
+	"b2e60e1f62d4a896f9c525598472394c" +
+	"5e78388cd1a5e261b3219c1e43c7fc04" +
+	"51996d0dfadf7e243babce118f4eb7eb" +
+	"3c8194f7b9132cd3e76ec40356447fa9" +
+	"2abbc153dc0b9d6c3174f646ac8914e1" +
+	"163a690970b6d0edcc4298a4285cf886"
+
+func TestCustomSBox(t *testing.T) {
+	var s [256]byte
+	hex.Decode(s[:], []byte(whirlpoolSBox))
+	w, err := whirlpool.NewCustomSBox(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range golden {
+		w.Reset()
+		w.Write([]byte(g.in))
+		if s := fmt.Sprintf("%X", w.Sum(nil)); s != g.out {
+			t.Fatalf("standard S-box: whirlpool(%q) = %s want %s", g.in, s, g.out)
+		}
+	}
+
+	// An affine permutation, with digests from an independent
+	// implementation.
+	for x := range s {
+		s[x] = byte(x*167 + 13)
+	}
+	w, err = whirlpool.NewCustomSBox(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range []whirlpoolTest{
+		{"06187A13C7D25BCB469971CA3FA38EA14EF954847595FF05E1BB99934CF6C268520F1E8EFF9A121C598F17B5259E9A0B1AAEC4EB8423893DBAF10FD67B405C50", ""},
+		{"A3C054EFB16FD9A05B084B543EA6EF2ECC6607C5B4CAFEE3B69CAA6D24C019537B9DBC77D2BBEA6330FABFC5A5569B69B92B9ED40EB3683480A70E9D95F54BC5", "abc"},
+	} {
+		w.Reset()
+		w.Write([]byte(g.in))
+		if s := fmt.Sprintf("%X", w.Sum(nil)); s != g.out {
+			t.Fatalf("custom S-box: whirlpool(%q) = %s want %s", g.in, s, g.out)
+		}
+	}
+
+	s[1] = s[0]
+	if _, err := whirlpool.NewCustomSBox(&s); err == nil {
+		t.Fatalf("S-box with a repeated value accepted")
+	}
+}
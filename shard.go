@@ -0,0 +1,70 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+// The helpers below read d as eight 64-bit big-endian words. Whirlpool
+// output is uniformly distributed, so each word is a uniform 64-bit value,
+// and the derivations are stable across versions and platforms: the same
+// digest always lands in the same shard and gets the same sampling
+// decision.
+
+// word returns the i-th 64-bit big-endian word of d.
+func (d *Digest) word(i int) uint64 {
+	return binary.BigEndian.Uint64(d[8*i:])
+}
+
+// Shard maps d to one of n shards, 0 to n-1, without modulo bias: it
+// returns w mod n for the first word w of d below the largest multiple of n
+// that fits in 64 bits, so every shard is exactly equally likely. Only if
+// all eight words are rejected, which for any n has a probability below
+// 2^-64, does it fall back to the last word mod n. It panics if n < 1.
+func (d Digest) Shard(n int) int {
+	if n < 1 {
+		panic("whirlpool: shard count must be positive")
+	}
+	un := uint64(n)
+	limit := math.MaxUint64 - (math.MaxUint64%un+1)%un // Largest multiple of n, minus 1.
+	w := d.word(0)
+	for i := 1; w > limit && i < Size/8; i++ {
+		w = d.word(i)
+	}
+	return int(w % un)
+}
+
+// ShardRange maps d to one of n shards, 0 to n-1, by splitting the range of
+// the first word of d into n contiguous parts. Unlike Shard, growing n to
+// a multiple k*n only splits every shard into k, so data moves only within
+// the ranges of former shards. Every shard covers either floor(2^64/n) or
+// one more of the 2^64 word values, a relative bias below n/2^64. It
+// panics if n < 1.
+func (d Digest) ShardRange(n int) int {
+	if n < 1 {
+		panic("whirlpool: shard count must be positive")
+	}
+	hi, _ := bits.Mul64(d.word(0), uint64(n))
+	return int(hi)
+}
+
+// Sample reports whether d is sampled at the rate p, that is whether the
+// first word of d, read as a fraction of 2^64, is below p. The decision is
+// consistent: independent systems sampling the same digests at the same
+// rate agree, and a digest sampled at rate p is also sampled at every
+// higher rate. p is rounded to a multiple of 2^-64; p <= 0 samples nothing
+// and p >= 1 everything.
+func (d Digest) Sample(p float64) bool {
+	switch {
+	case !(p > 0):
+		return false
+	case p >= 1:
+		return true
+	}
+	return d.word(0) < uint64(math.Ldexp(p, 64))
+}
@@ -0,0 +1,94 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestShard(t *testing.T) {
+	const n, samples = 7, 7000
+	var counts [n]int
+	for i := 0; i < samples; i++ {
+		d := whirlpool.Sum512([]byte(fmt.Sprint(i)))
+		s := d.Shard(n)
+		if s < 0 || s >= n {
+			t.Fatalf("shard %d out of range", s)
+		}
+		if d.Shard(n) != s {
+			t.Fatalf("Shard is not deterministic")
+		}
+		counts[s]++
+	}
+	for s, c := range counts {
+		if c < samples/n*8/10 || c > samples/n*12/10 {
+			t.Fatalf("shard %d got %d of %d digests", s, c, samples)
+		}
+	}
+
+	// A first word above the largest multiple of n is rejected in favor of
+	// the next one.
+	var d whirlpool.Digest
+	binary.BigEndian.PutUint64(d[0:], math.MaxUint64)
+	binary.BigEndian.PutUint64(d[8:], 10)
+	if s := d.Shard(3); s != 1 {
+		t.Fatalf("Shard(3) = %d want 1", s)
+	}
+	if s := d.Shard(1 << 40); s != int(uint64(math.MaxUint64)%(1<<40)) {
+		t.Fatalf("power of two rejected a word: %d", s)
+	}
+}
+
+func TestShardRange(t *testing.T) {
+	var d whirlpool.Digest
+	for _, tt := range []struct {
+		word uint64
+		n    int
+		want int
+	}{
+		{0, 4, 0},
+		{1<<62 - 1, 4, 0},
+		{1 << 62, 4, 1},
+		{math.MaxUint64, 4, 3},
+		{math.MaxUint64, 1, 0},
+	} {
+		binary.BigEndian.PutUint64(d[:], tt.word)
+		if s := d.ShardRange(tt.n); s != tt.want {
+			t.Errorf("ShardRange(%d) of %#x = %d want %d", tt.n, tt.word, s, tt.want)
+		}
+	}
+
+	// Doubling the shard count splits every shard in two.
+	for i := 0; i < 1000; i++ {
+		d := whirlpool.Sum512([]byte(fmt.Sprint(i)))
+		if d.ShardRange(16)/2 != d.ShardRange(8) {
+			t.Fatalf("digest %d moved between shard ranges", i)
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		d := whirlpool.Sum512([]byte(fmt.Sprint(i)))
+		if d.Sample(0.1) {
+			sampled++
+			if !d.Sample(0.2) {
+				t.Fatalf("digest sampled at 0.1 but not at 0.2")
+			}
+		}
+		if d.Sample(0) || d.Sample(math.NaN()) || !d.Sample(1) {
+			t.Fatalf("wrong decision at the extremes")
+		}
+	}
+	if sampled < 800 || sampled > 1200 {
+		t.Fatalf("sampled %d of 10000 at rate 0.1", sampled)
+	}
+}
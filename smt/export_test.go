@@ -0,0 +1,10 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package smt
+
+// NodesHashed returns the number of inner nodes t has hashed so far.
+func (t *Tree) NodesHashed() int {
+	return t.hashed
+}
@@ -0,0 +1,252 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package smt implements a sparse Merkle tree keyed by whirlpool digests,
+// an authenticated key-value map with inclusion and non-inclusion proofs.
+//
+// The tree has one leaf for each of the 2^512 possible keys, addressed by
+// the bits of the key from the most significant bit down. A present leaf is
+// hashed as H(0x00 || key || value), an empty leaf is 64 zero bytes and an
+// inner node is H(0x01 || left || right). Subtrees without any present leaf
+// have well-known default hashes, which are precomputed and never stored.
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"sort"
+
+	"github.com/tdx/whirlpool"
+)
+
+const (
+	// KeySize is the size of a key in bytes.
+	KeySize = 64
+	// Depth is the number of levels below the root.
+	Depth = 8 * KeySize
+)
+
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+var errKeySize = errors.New("smt: key must be 64 bytes")
+
+// defaults[h] is the hash of an empty subtree of height h.
+var defaults = func() [Depth + 1][]byte {
+	var d [Depth + 1][]byte
+	d[0] = make([]byte, KeySize)
+	for h := 1; h <= Depth; h++ {
+		d[h] = hashNode(d[h-1], d[h-1])
+	}
+	return d
+}()
+
+type key [KeySize]byte
+
+// Tree is a sparse Merkle tree. The zero value is not usable; use New.
+//
+// The hashes of inner nodes are cached, and Update only invalidates those on
+// the path to its key, so Root and Prove after an update rehash O(Depth)
+// nodes rather than the whole tree.
+type Tree struct {
+	leaves map[key][]byte
+	keys   []key           // Keys of leaves, sorted.
+	nodes  map[node][]byte // Cached hashes of non-empty subtrees.
+	hashed int             // Number of inner nodes hashed, for tests.
+}
+
+// node identifies the subtree at depth d whose keys start with the first d
+// bits of prefix; the other bits of prefix are zero.
+type node struct {
+	d      int
+	prefix key
+}
+
+// nodeOf returns the subtree at depth d that holds k.
+func nodeOf(k key, d int) node {
+	n := node{d: d}
+	copy(n.prefix[:d/8], k[:d/8])
+	if d%8 != 0 {
+		n.prefix[d/8] = k[d/8] &^ (0xff >> uint(d%8))
+	}
+	return n
+}
+
+// Proof proves the value, or absence, of a key. Only the siblings that are
+// not default hashes are included.
+type Proof struct {
+	// Bitmap has bit h set, counting from the least significant bit of the
+	// last byte, if the sibling at height h is included in Siblings.
+	Bitmap [Depth / 8]byte
+	// Siblings are the non-default siblings from the leaf level upwards.
+	Siblings [][]byte
+}
+
+// New returns an empty sparse Merkle tree.
+func New() *Tree {
+	return &Tree{leaves: make(map[key][]byte), nodes: make(map[node][]byte)}
+}
+
+// Get returns the value stored under k and whether it is present.
+func (t *Tree) Get(k []byte) ([]byte, bool) {
+	if len(k) != KeySize {
+		return nil, false
+	}
+	v, ok := t.leaves[key(k)]
+	return v, ok
+}
+
+// Update stores value under k. A nil value removes k from the tree.
+func (t *Tree) Update(k, value []byte) error {
+	if len(k) != KeySize {
+		return errKeySize
+	}
+	kk := key(k)
+	i, present := slices.BinarySearchFunc(t.keys, kk, func(a, b key) int { return bytes.Compare(a[:], b[:]) })
+	switch {
+	case value == nil && !present:
+		return nil
+	case value == nil:
+		delete(t.leaves, kk)
+		t.keys = slices.Delete(t.keys, i, i+1)
+	default:
+		t.leaves[kk] = append([]byte{}, value...)
+		if !present {
+			t.keys = slices.Insert(t.keys, i, kk)
+		}
+	}
+	for d := 0; d < Depth; d++ {
+		delete(t.nodes, nodeOf(kk, d))
+	}
+	return nil
+}
+
+// Root returns the root hash of the tree.
+func (t *Tree) Root() []byte {
+	return t.subtree(t.keys, 0)
+}
+
+// Prove returns a proof for the value stored under k, or for its absence.
+func (t *Tree) Prove(k []byte) (*Proof, error) {
+	if len(k) != KeySize {
+		return nil, errKeySize
+	}
+	var (
+		p    = new(Proof)
+		sibs = make([][]byte, Depth) // Indexed by height.
+		keys = t.keys
+	)
+	for d := 0; d < Depth; d++ {
+		split := sort.Search(len(keys), func(i int) bool { return bit(keys[i][:], d) == 1 })
+		left, right := keys[:split], keys[split:]
+		if bit(k, d) == 0 {
+			sibs[Depth-1-d] = t.subtree(right, d+1)
+			keys = left
+		} else {
+			sibs[Depth-1-d] = t.subtree(left, d+1)
+			keys = right
+		}
+	}
+	for h, s := range sibs {
+		if !bytes.Equal(s, defaults[h]) {
+			p.Bitmap[len(p.Bitmap)-1-h/8] |= 1 << uint(h%8)
+			p.Siblings = append(p.Siblings, s)
+		}
+	}
+	return p, nil
+}
+
+// Verify reports whether p proves that k maps to value in the tree with the
+// given root. A nil value verifies that k is absent.
+func Verify(root, k, value []byte, p *Proof) bool {
+	if p == nil || len(k) != KeySize {
+		return false
+	}
+	node := defaults[0]
+	if value != nil {
+		node = hashLeaf(k, value)
+	}
+
+	sibs := p.Siblings
+	for h := 0; h < Depth; h++ {
+		sib := defaults[h]
+		if p.Bitmap[len(p.Bitmap)-1-h/8]&(1<<uint(h%8)) != 0 {
+			if len(sibs) == 0 {
+				return false
+			}
+			sib, sibs = sibs[0], sibs[1:]
+		}
+		if bit(k, Depth-1-h) == 0 {
+			node = hashNode(node, sib)
+		} else {
+			node = hashNode(sib, node)
+		}
+	}
+	return len(sibs) == 0 && bytes.Equal(node, root)
+}
+
+// subtree returns the hash of the subtree at depth d holding the sorted keys,
+// which all share the same first d bits.
+func (t *Tree) subtree(keys []key, d int) []byte {
+	if len(keys) == 0 {
+		return defaults[Depth-d]
+	}
+	if d == Depth {
+		return hashLeaf(keys[0][:], t.leaves[keys[0]])
+	}
+	n := nodeOf(keys[0], d)
+	if h, ok := t.nodes[n]; ok {
+		return h
+	}
+	var h []byte
+	if len(keys) == 1 {
+		h = t.single(keys[0], d)
+	} else {
+		split := sort.Search(len(keys), func(i int) bool { return bit(keys[i][:], d) == 1 })
+		h = hashNode(t.subtree(keys[:split], d+1), t.subtree(keys[split:], d+1))
+		t.hashed++
+	}
+	t.nodes[n] = h
+	return h
+}
+
+// single returns the hash of the subtree at depth d holding only k. The
+// nodes below d are not cached, so a leaf costs one cache entry rather than
+// one per level.
+func (t *Tree) single(k key, d int) []byte {
+	node := hashLeaf(k[:], t.leaves[k])
+	for dd := Depth - 1; dd >= d; dd-- {
+		if bit(k[:], dd) == 0 {
+			node = hashNode(node, defaults[Depth-1-dd])
+		} else {
+			node = hashNode(defaults[Depth-1-dd], node)
+		}
+		t.hashed++
+	}
+	return node
+}
+
+// bit returns bit d of k, counting from the most significant bit.
+func bit(k []byte, d int) byte {
+	return (k[d/8] >> uint(7-d%8)) & 1
+}
+
+func hashLeaf(k, value []byte) []byte {
+	w := whirlpool.New()
+	w.Write([]byte{leafPrefix})
+	w.Write(k)
+	w.Write(value)
+	return w.Sum(nil)
+}
+
+func hashNode(left, right []byte) []byte {
+	w := whirlpool.New()
+	w.Write([]byte{nodePrefix})
+	w.Write(left)
+	w.Write(right)
+	return w.Sum(nil)
+}
@@ -0,0 +1,141 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package smt_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+	"github.com/tdx/whirlpool/smt"
+)
+
+func key(i int) []byte {
+	w := whirlpool.New()
+	fmt.Fprintf(w, "key %d", i)
+	return w.Sum(nil)
+}
+
+func TestTree(t *testing.T) {
+	tr := smt.New()
+	empty := tr.Root()
+
+	for i := 0; i < 8; i++ {
+		if err := tr.Update(key(i), []byte(fmt.Sprint("value ", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root := tr.Root()
+	if bytes.Equal(root, empty) {
+		t.Fatalf("root did not change")
+	}
+
+	for i := 0; i < 10; i++ {
+		v, ok := tr.Get(key(i))
+		if want := i < 8; ok != want {
+			t.Fatalf("Get(key %d) present = %v want %v", i, ok, want)
+		}
+		p, err := tr.Prove(key(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !smt.Verify(root, key(i), v, p) {
+			t.Fatalf("proof for key %d does not verify", i)
+		}
+		if smt.Verify(root, key(i), []byte("forged"), p) {
+			t.Fatalf("proof for key %d verifies a forged value", i)
+		}
+		if ok && smt.Verify(root, key(i), nil, p) {
+			t.Fatalf("proof for present key %d verifies absence", i)
+		}
+	}
+
+	// Removing every key restores the empty root.
+	for i := 0; i < 8; i++ {
+		tr.Update(key(i), nil)
+	}
+	if !bytes.Equal(tr.Root(), empty) {
+		t.Fatalf("root of emptied tree differs from empty root")
+	}
+}
+
+func TestTreeOrderIndependent(t *testing.T) {
+	a, b := smt.New(), smt.New()
+	for i := 0; i < 5; i++ {
+		a.Update(key(i), []byte{byte(i)})
+		b.Update(key(4-i), []byte{byte(4 - i)})
+	}
+	if !bytes.Equal(a.Root(), b.Root()) {
+		t.Fatalf("root depends on insertion order")
+	}
+}
+
+func TestProofTampering(t *testing.T) {
+	tr := smt.New()
+	tr.Update(key(1), []byte("a"))
+	tr.Update(key(2), []byte("b"))
+	root := tr.Root()
+	p, _ := tr.Prove(key(1))
+
+	bad := *p
+	bad.Siblings = append([][]byte{}, p.Siblings...)
+	bad.Siblings[0] = make([]byte, 64)
+	if smt.Verify(root, key(1), []byte("a"), &bad) {
+		t.Fatalf("tampered sibling verified")
+	}
+	bad = *p
+	bad.Siblings = append(append([][]byte{}, p.Siblings...), make([]byte, 64))
+	if smt.Verify(root, key(1), []byte("a"), &bad) {
+		t.Fatalf("proof with extra siblings verified")
+	}
+	if smt.Verify(root, key(1)[:10], []byte("a"), p) {
+		t.Fatalf("short key verified")
+	}
+	if err := tr.Update([]byte("short"), []byte("x")); err == nil {
+		t.Fatalf("short key accepted")
+	}
+}
+
+func TestTreeIncremental(t *testing.T) {
+	tr := smt.New()
+	values := make(map[int][]byte)
+	for i := 0; i < 256; i++ {
+		tr.Update(key(i), []byte{byte(i)})
+		values[i] = []byte{byte(i)}
+	}
+	tr.Root()
+	full := tr.NodesHashed()
+
+	for _, u := range []struct {
+		i     int
+		value []byte
+	}{
+		{1000, []byte("new")},
+		{7, []byte("changed")},
+		{1000, nil},
+		{8, nil},
+	} {
+		before := tr.NodesHashed()
+		tr.Update(key(u.i), u.value)
+		root := tr.Root()
+		if n := tr.NodesHashed() - before; n > 2*smt.Depth {
+			t.Fatalf("update of key %d rehashed %d nodes, %d for the whole tree", u.i, n, full)
+		}
+
+		if u.value == nil {
+			delete(values, u.i)
+		} else {
+			values[u.i] = u.value
+		}
+		fresh := smt.New()
+		for i, v := range values {
+			fresh.Update(key(i), v)
+		}
+		if !bytes.Equal(root, fresh.Root()) {
+			t.Fatalf("root after update of key %d differs from a fresh tree", u.i)
+		}
+	}
+}
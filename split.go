@@ -0,0 +1,154 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Split cuts the file name into parts of partSize bytes, the last of which
+// may be shorter, named name.000, name.001 and so on, for transport on media
+// smaller than the file. It also writes a control file, name.parts, listing
+// the whirlpool checksums of the parts in order followed by the checksum of
+// the whole file, in the format of WriteSignatures with FormatGNU. Split
+// returns the name of the control file, which Join takes to reassemble the
+// file.
+func Split(name string, partSize int64) (control string, err error) {
+	if partSize <= 0 {
+		return "", errors.New("whirlpool: part size must be positive")
+	}
+	src, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	var (
+		sigs  []Signature
+		whole = New()
+		base  = filepath.Base(name)
+	)
+	for i := 0; ; i++ {
+		part := fmt.Sprintf("%s.%03d", name, i)
+		n, sum, err := writePart(part, io.TeeReader(io.LimitReader(src, partSize), whole))
+		if err != nil {
+			return "", err
+		}
+		if n == 0 && i > 0 {
+			// The previous part ended exactly at EOF.
+			if err := os.Remove(part); err != nil {
+				return "", err
+			}
+			break
+		}
+		sigs = append(sigs, Signature{Path: filepath.Base(part), Sum: sum})
+		if n < partSize {
+			break
+		}
+	}
+	sigs = append(sigs, Signature{Path: base, Sum: whole.Sum(nil)})
+
+	control = name + ".parts"
+	f, err := os.Create(control)
+	if err != nil {
+		return "", err
+	}
+	if err := WriteSignatures(f, FormatGNU, sigs); err != nil {
+		f.Close()
+		return "", err
+	}
+	return control, f.Close()
+}
+
+// writePart copies r to the new file name and returns its length and
+// checksum.
+func writePart(name string, r io.Reader) (int64, []byte, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return 0, nil, err
+	}
+	w := New()
+	n, err := io.Copy(io.MultiWriter(f, w), r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return n, w.Sum(nil), err
+}
+
+// Join reassembles the file described by the control file written by Split
+// into dst, verifying every part, which is looked for in the directory of
+// the control file, and the whole file while copying. As with CopyVerified,
+// dst is only replaced once all checksums match. Join returns ErrMismatch,
+// wrapped with the name of the part, if a part is corrupt.
+func Join(control, dst string) error {
+	f, err := os.Open(control)
+	if err != nil {
+		return err
+	}
+	sigs, err := ParseSignatures(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	if len(sigs) < 2 {
+		return errors.New("whirlpool: control file lists no parts")
+	}
+
+	parts, whole := sigs[:len(sigs)-1], sigs[len(sigs)-1]
+	jr := &joinReader{dir: filepath.Dir(control), parts: parts, h: New()}
+	defer jr.close()
+	_, err = CopyVerified(dst, jr, whole.Sum)
+	return err
+}
+
+// joinReader reads the concatenation of parts, verifying each of them.
+type joinReader struct {
+	dir   string
+	parts []Signature
+	f     *os.File // Current part, nil between parts.
+	h     hash.Hash
+}
+
+func (j *joinReader) Read(p []byte) (int, error) {
+	for {
+		if j.f == nil {
+			if len(j.parts) == 0 {
+				return 0, io.EOF
+			}
+			f, err := os.Open(filepath.Join(j.dir, j.parts[0].Path))
+			if err != nil {
+				return 0, err
+			}
+			j.f = f
+			j.h.Reset()
+		}
+		n, err := j.f.Read(p)
+		j.h.Write(p[:n])
+		if err == io.EOF {
+			j.close()
+			if !bytes.Equal(j.h.Sum(nil), j.parts[0].Sum) {
+				return n, fmt.Errorf("whirlpool: part %s: %w", j.parts[0].Path, ErrMismatch)
+			}
+			j.parts = j.parts[1:]
+			err = nil
+		}
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+func (j *joinReader) close() {
+	if j.f != nil {
+		j.f.Close()
+		j.f = nil
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestSplitJoin(t *testing.T) {
+	data := []byte(strings.Repeat("0123456789", 1000))
+
+	for _, tt := range []struct {
+		size  int64
+		parts int
+	}{{3000, 4}, {2500, 4}, {10000, 1}, {20000, 1}} {
+		dir := t.TempDir()
+		name := filepath.Join(dir, "archive.tar")
+		if err := os.WriteFile(name, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		control, err := whirlpool.Split(name, tt.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts, _ := filepath.Glob(name + ".[0-9]*")
+		if len(parts) != tt.parts {
+			t.Fatalf("size %d: got parts %v want %d", tt.size, parts, tt.parts)
+		}
+
+		dst := filepath.Join(dir, "joined.tar")
+		if err := whirlpool.Join(control, dst); err != nil {
+			t.Fatalf("size %d: %v", tt.size, err)
+		}
+		if got, _ := os.ReadFile(dst); !bytes.Equal(got, data) {
+			t.Fatalf("size %d: joined file differs", tt.size)
+		}
+	}
+}
+
+func TestJoinCorruptPart(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "archive.tar")
+	if err := os.WriteFile(name, []byte(strings.Repeat("0123456789", 1000)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	control, err := whirlpool.Split(name, 3000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part := name + ".002"
+	p, _ := os.ReadFile(part)
+	p[10] ^= 1
+	os.WriteFile(part, p, 0644)
+
+	dst := filepath.Join(dir, "joined.tar")
+	err = whirlpool.Join(control, dst)
+	if !errors.Is(err, whirlpool.ErrMismatch) || !strings.Contains(err.Error(), "archive.tar.002") {
+		t.Fatalf("got %v want mismatch of part 002", err)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatalf("partial output left behind: %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// Type tags of the value encoding used by HashStruct.
+const (
+	tagNil    = 'n'
+	tagBool   = 'b'
+	tagInt    = 'i'
+	tagUint   = 'u'
+	tagFloat  = 'f'
+	tagString = 's'
+	tagBytes  = 'y'
+	tagList   = 'l'
+	tagMap    = 'm'
+	tagStruct = 't'
+)
+
+// maxDepth bounds the nesting of values accepted by HashStruct so that
+// cyclic data structures fail instead of recursing forever.
+const maxDepth = 64
+
+// HashStruct returns the whirlpool checksum of a deterministic encoding of
+// v, for use as a cache key or configuration fingerprint.
+//
+// Every value is encoded as a one byte type tag followed by its payload.
+// Integers, unsigned integers and floats are widened to 64 bits and written
+// big-endian, with every NaN written as the same quiet NaN; strings and byte
+// slices are prefixed with their length as a 64-bit big-endian integer;
+// slices and arrays with their element count. Maps are written as their
+// entry count followed by the encoded entries in the byte order of their
+// encoded keys, and of their encoded values where keys encode the same, so
+// the result does not depend on map iteration order.
+// Structs are written as their field count followed by the name and value of
+// every exported field; fields tagged `whirlpool:"-"` are skipped and
+// `whirlpool:"name"` overrides the field name. Nil pointers, interfaces,
+// slices and maps are written as the nil tag; other pointers and interfaces
+// as the value they refer to.
+//
+// Channels, functions, complex numbers and unsafe pointers cannot be
+// encoded and make HashStruct return an error.
+func HashStruct(v any) (Digest, error) {
+	var w whirlpool
+	e := encoder{w: &w}
+	if err := e.encode(reflect.ValueOf(v), 0); err != nil {
+		return Digest{}, err
+	}
+	return w.checkSum(), nil
+}
+
+// encoder writes the HashStruct encoding of values to w.
+type encoder struct {
+	w       io.Writer
+	scratch [9]byte
+}
+
+func (e *encoder) tag(t byte) {
+	e.scratch[0] = t
+	e.w.Write(e.scratch[:1])
+}
+
+func (e *encoder) tagUint64(t byte, v uint64) {
+	e.scratch[0] = t
+	binary.BigEndian.PutUint64(e.scratch[1:], v)
+	e.w.Write(e.scratch[:])
+}
+
+func (e *encoder) bytes(t byte, b []byte) {
+	e.tagUint64(t, uint64(len(b)))
+	e.w.Write(b)
+}
+
+func (e *encoder) string(s string) {
+	e.tagUint64(tagString, uint64(len(s)))
+	e.w.Write([]byte(s))
+}
+
+// canonicalNaN is the quiet NaN every NaN is encoded as, since the payload
+// and sign of a NaN produced by arithmetic differ between architectures.
+const canonicalNaN = 0x7ff8000000000000
+
+func (e *encoder) float(f float64) {
+	b := math.Float64bits(f)
+	if f != f {
+		b = canonicalNaN
+	}
+	e.tagUint64(tagFloat, b)
+}
+
+func (e *encoder) encode(v reflect.Value, depth int) error {
+	if depth > maxDepth {
+		return errors.New("whirlpool: value nested too deeply")
+	}
+	if !v.IsValid() {
+		e.tag(tagNil)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		e.scratch[0], e.scratch[1] = tagBool, 0
+		if v.Bool() {
+			e.scratch[1] = 1
+		}
+		e.w.Write(e.scratch[:2])
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.tagUint64(tagInt, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.tagUint64(tagUint, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		e.float(v.Float())
+	case reflect.String:
+		e.string(v.String())
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			e.tag(tagNil)
+			return nil
+		}
+		return e.encode(v.Elem(), depth+1)
+	case reflect.Slice:
+		if v.IsNil() {
+			e.tag(tagNil)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.bytes(tagBytes, v.Bytes())
+			return nil
+		}
+		return e.list(v, depth)
+	case reflect.Array:
+		return e.list(v, depth)
+	case reflect.Map:
+		if v.IsNil() {
+			e.tag(tagNil)
+			return nil
+		}
+		return e.mapping(v, depth)
+	case reflect.Struct:
+		return e.structure(v, depth)
+	default:
+		return fmt.Errorf("whirlpool: cannot hash value of type %s", v.Type())
+	}
+	return nil
+}
+
+func (e *encoder) list(v reflect.Value, depth int) error {
+	e.tagUint64(tagList, uint64(v.Len()))
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) mapping(v reflect.Value, depth int) error {
+	type entry struct {
+		key, val []byte
+	}
+
+	// Encode the entries on their own to sort them. Distinct keys can encode
+	// the same, such as int8(1) and int64(1) in a map[any]T, so entries are
+	// ordered by their values too.
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		var key, val bytes.Buffer
+		if err := (&encoder{w: &key}).encode(iter.Key(), depth+1); err != nil {
+			return err
+		}
+		if err := (&encoder{w: &val}).encode(iter.Value(), depth+1); err != nil {
+			return err
+		}
+		entries = append(entries, entry{key.Bytes(), val.Bytes()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if c := bytes.Compare(entries[i].key, entries[j].key); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(entries[i].val, entries[j].val) < 0
+	})
+
+	e.tagUint64(tagMap, uint64(len(entries)))
+	for _, en := range entries {
+		e.w.Write(en.key)
+		e.w.Write(en.val)
+	}
+	return nil
+}
+
+func (e *encoder) structure(v reflect.Value, depth int) error {
+	t := v.Type()
+	type field struct {
+		name  string
+		index int
+	}
+	fields := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // Unexported.
+		}
+		name := f.Name
+		switch tag := f.Tag.Get("whirlpool"); tag {
+		case "-":
+			continue
+		case "":
+		default:
+			name = tag
+		}
+		fields = append(fields, field{name, i})
+	}
+
+	e.tagUint64(tagStruct, uint64(len(fields)))
+	for _, f := range fields {
+		e.string(f.name)
+		if err := e.encode(v.Field(f.index), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+type config struct {
+	Name    string
+	Workers int
+	Ratio   float64
+	Tags    []string
+	Limits  map[string]uint
+	Next    *config
+	Secret  string `whirlpool:"-"`
+	Renamed bool   `whirlpool:"enabled"`
+	private int
+}
+
+func mustHashStruct(t *testing.T, v any) whirlpool.Digest {
+	t.Helper()
+	s, err := whirlpool.HashStruct(v)
+	if err != nil {
+		t.Fatalf("HashStruct(%#v): %v", v, err)
+	}
+	return s
+}
+
+func TestHashStructDeterministic(t *testing.T) {
+	a := config{
+		Name:    "a",
+		Workers: 4,
+		Tags:    []string{"x", "y"},
+		Limits:  map[string]uint{"cpu": 2, "mem": 512, "io": 9, "net": 1},
+		Next:    &config{Name: "b"},
+		Secret:  "one",
+		private: 1,
+	}
+	b := a
+	b.Limits = map[string]uint{"net": 1, "io": 9, "mem": 512, "cpu": 2}
+	b.Next = &config{Name: "b"}
+	b.Secret = "two"
+	b.private = 2
+
+	if mustHashStruct(t, a) != mustHashStruct(t, b) {
+		t.Fatalf("equal values hash differently")
+	}
+	if mustHashStruct(t, a) != mustHashStruct(t, &a) {
+		t.Fatalf("pointer and value hash differently")
+	}
+}
+
+func TestHashStructEqualKeys(t *testing.T) {
+	// The keys encode the same, so only their values order the entries.
+	nan := math.NaN()
+	for _, v := range []any{
+		map[any]int{int8(1): 1, int64(1): 2, int(1): 3},
+		map[float64]string{nan: "a", nan: "b", nan: "c"},
+	} {
+		want := mustHashStruct(t, v)
+		for i := 0; i < 100; i++ {
+			if got := mustHashStruct(t, v); got != want {
+				t.Fatalf("HashStruct(%v) = %s, then %s", v, want, got)
+			}
+		}
+	}
+}
+
+func TestHashStructDistinct(t *testing.T) {
+	base := config{Name: "a", Tags: []string{"x", "y"}}
+	variants := []any{
+		config{Name: "b", Tags: []string{"x", "y"}},
+		config{Name: "a", Tags: []string{"y", "x"}},
+		config{Name: "a", Tags: []string{"xy"}},
+		config{Name: "a", Tags: []string{"x", "y"}, Renamed: true},
+		config{Name: "a", Tags: []string{"x", "y"}, Limits: map[string]uint{}},
+		config{Name: "a", Tags: []string{"x", "y"}, Next: &config{}},
+		struct{ Name string }{"a"},
+		[]any{int64(1)},
+		[]any{uint64(1)},
+		[]any{1.0},
+		"a",
+		[]byte("a"),
+		nil,
+	}
+	seen := map[whirlpool.Digest]int{mustHashStruct(t, base): -1}
+	for i, v := range variants {
+		s := mustHashStruct(t, v)
+		if j, dup := seen[s]; dup {
+			t.Fatalf("variant %d (%#v) collides with %d", i, v, j)
+		}
+		seen[s] = i
+	}
+}
+
+func TestHashStructUnsupported(t *testing.T) {
+	for _, v := range []any{make(chan int), func() {}, complex(1, 2), struct{ F func() }{}} {
+		if _, err := whirlpool.HashStruct(v); err == nil {
+			t.Fatalf("HashStruct(%T) succeeded", v)
+		}
+	}
+
+	type node struct{ Next *node }
+	n := &node{}
+	n.Next = n
+	if _, err := whirlpool.HashStruct(n); err == nil {
+		t.Fatalf("HashStruct of a cycle succeeded")
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// readBufferSize is the size of the buffers of ReadFrom, a multiple of the
+// block size so that full reads compress without buffering.
+const readBufferSize = 512 * wblockBytes
+
+var readBuffers = sync.Pool{
+	New: func() any { return new([readBufferSize]byte) },
+}
+
+// SumReader reads r to EOF and returns its whirlpool checksum and the
+// number of bytes read. The read buffer is reused across calls.
+func SumReader(r io.Reader) (Digest, int64, error) {
+	var w whirlpool
+	n, err := w.ReadFrom(r)
+	if err != nil {
+		return Digest{}, n, err
+	}
+	return w.checkSum(), n, nil
+}
+
+// SumFile returns the whirlpool checksum of the named file.
+func SumFile(name string) (Digest, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return Digest{}, err
+	}
+	defer f.Close()
+	d, _, err := SumReader(f)
+	return d, err
+}
@@ -0,0 +1,48 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestSumReader(t *testing.T) {
+	for _, size := range []int{0, 1, 64, 1000, 32768, 100000} {
+		data := seq(0, size)
+		for _, r := range []io.Reader{bytes.NewReader(data), iotest.HalfReader(bytes.NewReader(data))} {
+			d, n, err := whirlpool.SumReader(r)
+			if err != nil || n != int64(size) || d != whirlpool.Sum512(data) {
+				t.Fatalf("size %d: got %s, %d, %v", size, d, n, err)
+			}
+		}
+	}
+
+	errRead := errors.New("read failed")
+	if _, _, err := whirlpool.SumReader(iotest.ErrReader(errRead)); err != errRead {
+		t.Fatalf("got %v want %v", err, errRead)
+	}
+}
+
+func TestSumFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "file")
+	data := seq(0, 70000)
+	if err := os.WriteFile(name, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := whirlpool.SumFile(name); err != nil || d != whirlpool.Sum512(data) {
+		t.Fatalf("got %s, %v", d, err)
+	}
+	if _, err := whirlpool.SumFile(name + ".missing"); !os.IsNotExist(err) {
+		t.Fatalf("got %v want not exist", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+// tables holds the lookup tables of a whirlpool variant. Table k maps a byte
+// x to the row of the diffusion matrix multiplied by S[x], rotated right by
+// k bytes, so that a round is eight lookups per row of the state.
+type tables struct {
+	c      [8]*[256]uint64
+	rc     *[rounds + 1]uint64
+	rounds int    // Number of rounds, at most rounds.
+	magic  string // Identifies the variant in marshaled states.
+}
+
+// whirlpoolTables are the tables of whirlpool as standardized, used when a
+// whirlpool has no tables set.
+var whirlpoolTables = tables{
+	c:      [8]*[256]uint64{&_C0, &_C1, &_C2, &_C3, &_C4, &_C5, &_C6, &_C7},
+	rc:     &rc,
+	rounds: rounds,
+	magic:  magic,
+}
+
+// sbox returns the S-box of whirlpool, which is the first byte of every
+// entry of _C0 since the diffusion matrix starts with 1.
+func sbox() *[256]byte {
+	var s [256]byte
+	for x := range s {
+		s[x] = byte(_C0[x] >> 56)
+	}
+	return &s
+}
+
+// newTables computes the tables of the variant with the S-box s and the
+// circulant diffusion matrix whose first row is m.
+func newTables(s *[256]byte, m [8]byte, magic string) *tables {
+	t := &tables{rc: new([rounds + 1]uint64), rounds: rounds, magic: magic}
+	for k := range t.c {
+		t.c[k] = new([256]uint64)
+	}
+	for x := 0; x < 256; x++ {
+		var v uint64
+		for j := 0; j < 8; j++ {
+			v = v<<8 | uint64(gfMul(s[x], m[j]))
+		}
+		for k := range t.c {
+			t.c[k][x] = v>>(8*k) | v<<(64-8*k)
+		}
+	}
+	// The round constants are the S-box, eight bytes per round.
+	for r := 1; r <= rounds; r++ {
+		for j := 0; j < 8; j++ {
+			t.rc[r] = t.rc[r]<<8 | uint64(s[8*(r-1)+j])
+		}
+	}
+	return t
+}
+
+// gfMul multiplies a and b in GF(2^8) modulo the whirlpool polynomial
+// x^8 + x^4 + x^3 + x^2 + 1.
+func gfMul(a, b byte) byte {
+	var p byte
+	for ; b != 0; b >>= 1 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		hi := a & 0x80
+		a <<= 1
+		if hi != 0 {
+			a ^= 0x1d
+		}
+	}
+	return p
+}
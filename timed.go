@@ -0,0 +1,69 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+// timedChunk is how much SumTimed hashes between looking at the clock.
+const timedChunk = 64 * 1024
+
+// SumTimed hashes r from the start for at most about budget and returns
+// either its checksum or, if the budget ran out first, a continuation token
+// to pass to the next call to carry on where it stopped. Interactive tools
+// can thus hash large inputs in slices and keep their UI responsive in
+// between. Pass a nil token to start; each call hashes at least one chunk,
+// so that hashing always progresses.
+//
+// SumTimed returns a nil token once r is hashed to EOF, together with its
+// checksum. The token is the offset in r, as a 64-bit big-endian integer,
+// followed by the marshaled hash state, and can be stored to continue in
+// another process as long as r does not change.
+func SumTimed(r io.ReaderAt, token []byte, budget time.Duration) (sum Digest, next []byte, err error) {
+	deadline := time.Now().Add(budget)
+
+	var (
+		w   whirlpool
+		off int64
+	)
+	if token != nil {
+		if len(token) < 8 {
+			return Digest{}, nil, errors.New("whirlpool: invalid continuation token")
+		}
+		off = int64(binary.BigEndian.Uint64(token))
+		if err := w.UnmarshalBinary(token[8:]); err != nil {
+			return Digest{}, nil, err
+		}
+		// The offset must match the number of bytes hashed.
+		bits := binary.BigEndian.Uint64(w.bitLength[lengthBytes-8:])
+		if off < 0 || w.bufferBits%8 != 0 || bits != uint64(off)*8 {
+			return Digest{}, nil, errors.New("whirlpool: invalid continuation token")
+		}
+	}
+
+	buf := make([]byte, timedChunk)
+	for {
+		n, err := r.ReadAt(buf, off)
+		w.Write(buf[:n])
+		off += int64(n)
+		if err == io.EOF {
+			return w.checkSum(), nil, nil
+		}
+		if err != nil {
+			return Digest{}, nil, err
+		}
+		if !time.Now().Before(deadline) {
+			break
+		}
+	}
+
+	next = binary.BigEndian.AppendUint64(make([]byte, 0, 8+marshaledSize), uint64(off))
+	next, _ = w.AppendBinary(next)
+	return Digest{}, next, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestSumTimed(t *testing.T) {
+	data := seq(0, 1<<20+17)
+	r := bytes.NewReader(data)
+
+	// A zero budget hashes one chunk per call.
+	var (
+		token []byte
+		calls int
+		sum   whirlpool.Digest
+		err   error
+	)
+	for {
+		calls++
+		sum, token, err = whirlpool.SumTimed(r, token, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token == nil {
+			break
+		}
+	}
+	if sum != whirlpool.Sum512(data) {
+		t.Fatalf("got %s want %s", sum, whirlpool.Sum512(data))
+	}
+	if calls < 2 {
+		t.Fatalf("finished in %d calls", calls)
+	}
+
+	// A generous budget finishes at once.
+	sum, token, err = whirlpool.SumTimed(r, nil, time.Minute)
+	if err != nil || token != nil || sum != whirlpool.Sum512(data) {
+		t.Fatalf("got %s, %x, %v", sum, token, err)
+	}
+}
+
+func TestSumTimedInvalidToken(t *testing.T) {
+	r := bytes.NewReader(seq(0, 1<<20))
+	_, token, err := whirlpool.SumTimed(r, nil, 0)
+	if err != nil || token == nil {
+		t.Fatalf("got %x, %v", token, err)
+	}
+	bad := append([]byte{}, token...)
+	bad[7]++ // Offset no longer matches the state.
+	for _, tok := range [][]byte{{1}, bad, token[:len(token)-1]} {
+		if _, _, err := whirlpool.SumTimed(r, tok, 0); err == nil {
+			t.Errorf("token %x accepted", tok)
+		}
+	}
+}
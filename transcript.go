@@ -0,0 +1,44 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import "encoding/binary"
+
+// Transcript hashes a sequence of labeled protocol messages, as used by
+// handshake protocols to bind every message exchanged so far.
+//
+// Each appended message is framed as
+//
+//	len(label) || label || len(msg) || msg
+//
+// with the lengths encoded as 64-bit big-endian integers, so two different
+// sequences of (label, msg) pairs never hash the same input.
+type Transcript struct {
+	w whirlpool
+}
+
+// NewTranscript returns an empty Transcript.
+func NewTranscript() *Transcript {
+	return new(Transcript)
+}
+
+// Append adds a labeled message to the transcript.
+func (t *Transcript) Append(label string, msg []byte) {
+	var l [8]byte
+	binary.BigEndian.PutUint64(l[:], uint64(len(label)))
+	t.w.Write(l[:])
+	t.w.Write([]byte(label))
+	binary.BigEndian.PutUint64(l[:], uint64(len(msg)))
+	t.w.Write(l[:])
+	t.w.Write(msg)
+}
+
+// Current returns the whirlpool checksum of the transcript so far. It does
+// not change the transcript, so more messages can be appended afterwards.
+func (t *Transcript) Current() Digest {
+	var d Digest
+	t.w.SumInto((*[Size]byte)(&d))
+	return d
+}
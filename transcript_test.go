@@ -0,0 +1,62 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestTranscriptFraming(t *testing.T) {
+	h := whirlpool.New()
+	h.Write([]byte{0, 0, 0, 0, 0, 0, 0, 5})
+	h.Write([]byte("hello"))
+	h.Write([]byte{0, 0, 0, 0, 0, 0, 0, 3})
+	h.Write([]byte("abc"))
+	want := whirlpool.Digest(h.Sum(nil))
+
+	tr := whirlpool.NewTranscript()
+	tr.Append("hello", []byte("abc"))
+	if got := tr.Current(); got != want {
+		t.Fatalf("Current() = %s want %s", got, want)
+	}
+}
+
+func TestTranscriptUnambiguous(t *testing.T) {
+	a := whirlpool.NewTranscript()
+	a.Append("ab", []byte("c"))
+	b := whirlpool.NewTranscript()
+	b.Append("a", []byte("bc"))
+	if a.Current() == b.Current() {
+		t.Fatalf("different label/message splits hash the same")
+	}
+
+	c := whirlpool.NewTranscript()
+	c.Append("m", []byte("xy"))
+	d := whirlpool.NewTranscript()
+	d.Append("m", []byte("x"))
+	d.Append("", []byte("y"))
+	if c.Current() == d.Current() {
+		t.Fatalf("different message sequences hash the same")
+	}
+}
+
+func TestTranscriptCurrentKeepsState(t *testing.T) {
+	a := whirlpool.NewTranscript()
+	a.Append("client hello", []byte("1"))
+	first := a.Current()
+	if again := a.Current(); first != again {
+		t.Fatalf("Current() changed the transcript")
+	}
+	a.Append("server hello", []byte("2"))
+
+	b := whirlpool.NewTranscript()
+	b.Append("client hello", []byte("1"))
+	b.Append("server hello", []byte("2"))
+	if a.Current() != b.Current() {
+		t.Fatalf("Append after Current diverged")
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import "hash"
+
+// Sizes of the truncated checksums in bytes.
+const (
+	Size256 = 32
+	Size384 = 48
+)
+
+// New256 returns a new hash.Hash computing the whirlpool checksum truncated
+// to its first 256 bits. Only the output is truncated: the state is that of
+// New, so marshaled states of both can be resumed by either.
+func New256() hash.Hash {
+	return &whirlpool{size: Size256}
+}
+
+// New384 returns a new hash.Hash computing the whirlpool checksum truncated
+// to its first 384 bits, like New256.
+func New384() hash.Hash {
+	return &whirlpool{size: Size384}
+}
+
+// Sum256 returns the whirlpool checksum of data truncated to 256 bits.
+func Sum256(data []byte) (sum [Size256]byte) {
+	d := Sum512(data)
+	copy(sum[:], d[:])
+	return sum
+}
+
+// Sum384 returns the whirlpool checksum of data truncated to 384 bits.
+func Sum384(data []byte) (sum [Size384]byte) {
+	d := Sum512(data)
+	copy(sum[:], d[:])
+	return sum
+}
@@ -0,0 +1,66 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"encoding"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestTruncated(t *testing.T) {
+	for _, tt := range []struct {
+		new  func() hash.Hash
+		sum  func([]byte) []byte
+		size int
+	}{
+		{whirlpool.New256, func(b []byte) []byte { s := whirlpool.Sum256(b); return s[:] }, 32},
+		{whirlpool.New384, func(b []byte) []byte { s := whirlpool.Sum384(b); return s[:] }, 48},
+	} {
+		for _, g := range golden {
+			want := g.out[:2*tt.size]
+			h := tt.new()
+			if h.Size() != tt.size || h.BlockSize() != whirlpool.BlockSize {
+				t.Fatalf("Size() = %d, BlockSize() = %d", h.Size(), h.BlockSize())
+			}
+			io.WriteString(h, g.in)
+			if s := fmt.Sprintf("%X", h.Sum([]byte{})); s != want {
+				t.Fatalf("whirlpool-%d(%q) = %s want %s", 8*tt.size, g.in, s, want)
+			}
+			out := [whirlpool.Size]byte{0: 1, whirlpool.Size - 1: 1}
+			h.(interface{ SumInto(*[whirlpool.Size]byte) }).SumInto(&out)
+			if s := fmt.Sprintf("%X", out); s != want+strings.Repeat("00", whirlpool.Size-tt.size) {
+				t.Fatalf("whirlpool-%d(%q) SumInto = %s want %s zero-filled", 8*tt.size, g.in, s, want)
+			}
+			c, _ := h.(hash.Cloner).Clone()
+			h.Reset()
+			if c.Size() != tt.size || h.Size() != tt.size {
+				t.Fatalf("Clone or Reset lost the truncation")
+			}
+			if s := fmt.Sprintf("%X", tt.sum([]byte(g.in))); s != want {
+				t.Fatalf("Sum%d(%q) = %s want %s", 8*tt.size, g.in, s, want)
+			}
+		}
+	}
+}
+
+func TestTruncatedMarshal(t *testing.T) {
+	h := whirlpool.New()
+	io.WriteString(h, "abc")
+	state, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+
+	r := whirlpool.New256()
+	if err := r.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	if s := fmt.Sprintf("%X", r.Sum(nil)); s != golden[3].out[:64] {
+		t.Fatalf("resumed whirlpool-256 = %s want %s", s, golden[3].out[:64])
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// VerifiedReader reads content that ReadVerified has already checked
+// against its digest.
+type VerifiedReader struct {
+	r    io.Reader
+	f    *os.File // Temporary file holding the content, if it spilled.
+	size int64
+}
+
+// ReadVerified reads r to EOF and checks it against the whirlpool checksum
+// sum before any of it is handed out, so that an upload endpoint never acts
+// on content whose claimed digest is wrong. Up to maxMemory bytes are kept in
+// memory; larger content is spilled to a temporary file, which Close
+// removes.
+//
+// ReadVerified returns ErrMismatch if the content does not match sum.
+func ReadVerified(r io.Reader, sum []byte, maxMemory int64) (_ *VerifiedReader, err error) {
+	var (
+		w   = New()
+		buf bytes.Buffer
+	)
+	n, err := io.CopyN(io.MultiWriter(&buf, w), r, maxMemory+1)
+	if err == io.EOF {
+		if !bytes.Equal(w.Sum(nil), sum) {
+			return nil, ErrMismatch
+		}
+		return &VerifiedReader{r: bytes.NewReader(buf.Bytes()), size: n}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp("", "whirlpool-verified-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err = buf.WriteTo(f); err != nil {
+		return nil, err
+	}
+	m, err := io.Copy(io.MultiWriter(f, w), r)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(w.Sum(nil), sum) {
+		return nil, ErrMismatch
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return &VerifiedReader{r: f, f: f, size: n + m}, nil
+}
+
+func (v *VerifiedReader) Read(p []byte) (int, error) {
+	return v.r.Read(p)
+}
+
+// Size returns the length of the content.
+func (v *VerifiedReader) Size() int64 {
+	return v.size
+}
+
+// Spilled reports whether the content was spilled to a temporary file.
+func (v *VerifiedReader) Spilled() bool {
+	return v.f != nil
+}
+
+// Close releases the content, removing the temporary file if there is one.
+func (v *VerifiedReader) Close() error {
+	if v.f == nil {
+		return nil
+	}
+	err := v.f.Close()
+	if rerr := os.Remove(v.f.Name()); err == nil {
+		err = rerr
+	}
+	return err
+}
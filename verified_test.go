@@ -0,0 +1,57 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestReadVerified(t *testing.T) {
+	data := []byte(strings.Repeat("upload ", 1000))
+
+	for _, limit := range []int64{0, 100, int64(len(data)) - 1, int64(len(data)), 1 << 20} {
+		r := iotest.HalfReader(bytes.NewReader(data))
+		v, err := whirlpool.ReadVerified(r, sum(data), limit)
+		if err != nil {
+			t.Fatalf("limit %d: %v", limit, err)
+		}
+		if spill := int64(len(data)) > limit; v.Spilled() != spill {
+			t.Fatalf("limit %d: Spilled() = %v want %v", limit, v.Spilled(), spill)
+		}
+		if v.Size() != int64(len(data)) {
+			t.Fatalf("limit %d: Size() = %d want %d", limit, v.Size(), len(data))
+		}
+		got, err := io.ReadAll(v)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("limit %d: content mismatch, %v", limit, err)
+		}
+		if err := v.Close(); err != nil {
+			t.Fatalf("limit %d: Close: %v", limit, err)
+		}
+	}
+}
+
+func TestReadVerifiedMismatch(t *testing.T) {
+	data := []byte(strings.Repeat("upload ", 1000))
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	for _, limit := range []int64{100, 1 << 20} {
+		v, err := whirlpool.ReadVerified(bytes.NewReader(data), sum([]byte("claimed")), limit)
+		if err != whirlpool.ErrMismatch || v != nil {
+			t.Fatalf("limit %d: got %v, %v want ErrMismatch", limit, v, err)
+		}
+	}
+	if ents, _ := os.ReadDir(tmp); len(ents) != 0 {
+		t.Fatalf("temporary files left behind: %v", ents)
+	}
+}
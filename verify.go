@@ -0,0 +1,184 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+)
+
+// ErrMismatch is reported when a file does not match its listed digest.
+var ErrMismatch = errors.New("whirlpool: checksum mismatch")
+
+// VerifyResult is the outcome of verifying a single digest list entry.
+type VerifyResult struct {
+	Line int    // Line number of the entry in the list, starting at 1.
+	Path string // Path of the file as given in the list.
+	Err  error  // Nil if the file matched its digest.
+}
+
+type verifyJob struct {
+	line int
+	path string
+	sum  []byte
+}
+
+// VerifyList reads lines of the form "digest  path", as written by the *sum
+// family of tools, or any of the other formats accepted by ParseSignatures,
+// from r and checks every listed file in fsys against its digest using up to
+// workers goroutines.
+//
+// Results are sent on the returned channel as they complete, so they are not
+// necessarily in list order. Malformed lines and read errors are reported as
+// results too. The channel is closed once the whole list has been processed;
+// callers must drain it.
+func VerifyList(r io.Reader, fsys fs.FS, workers int) <-chan VerifyResult {
+	return verifyList(r, fsys, workers, nil)
+}
+
+// VerifyListLimit is like VerifyList, but hashes at most limit(dev) files
+// stored on the device dev at the same time, so that a list spanning a
+// spinning disk and an SSD can use many workers without having them all
+// seek on the disk at once. The device of a file is its st_dev as reported
+// by fs.Stat; files whose device is unknown, such as on Windows or in file
+// systems not backed by the operating system, are not limited. limit is
+// called once per device and values below 1 mean 1.
+//
+// A worker waiting for a busy device does not pick up other entries in the
+// meantime, so workers should comfortably exceed the sum of the limits of
+// the slow devices.
+func VerifyListLimit(r io.Reader, fsys fs.FS, workers int, limit func(dev uint64) int) <-chan VerifyResult {
+	return verifyList(r, fsys, workers, &deviceLimiter{fsys: fsys, limit: limit})
+}
+
+func verifyList(r io.Reader, fsys fs.FS, workers int, dl *deviceLimiter) <-chan VerifyResult {
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan verifyJob)
+	results := make(chan VerifyResult)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				res := VerifyResult{Line: j.line, Path: j.path}
+				release := dl.acquire(j.path)
+				sum, err := sumFS(fsys, j.path)
+				release()
+				switch {
+				case err != nil:
+					res.Err = err
+				case !bytes.Equal(sum, j.sum):
+					res.Err = ErrMismatch
+				}
+				results <- res
+			}
+		}()
+	}
+
+	go func() {
+		sc := bufio.NewScanner(r)
+		line := 0
+		for sc.Scan() {
+			line++
+			text := strings.TrimSuffix(sc.Text(), "\r")
+			if isComment(text) {
+				continue
+			}
+			sum, path, err := parseSignatureLine(text)
+			if err != nil {
+				results <- VerifyResult{Line: line, Err: err}
+				continue
+			}
+			path = strings.TrimPrefix(path, "./")
+			jobs <- verifyJob{line: line, path: path, sum: sum}
+		}
+		close(jobs)
+		if err := sc.Err(); err != nil {
+			results <- VerifyResult{Line: line + 1, Err: err}
+		}
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// deviceLimiter bounds the number of concurrent readers per device.
+type deviceLimiter struct {
+	fsys  fs.FS
+	limit func(dev uint64) int
+
+	mu   sync.Mutex
+	sems map[uint64]chan struct{}
+}
+
+// acquire waits until the device of the named file has a free slot and
+// returns the function that gives it back. A nil limiter never waits.
+func (dl *deviceLimiter) acquire(name string) (release func()) {
+	if dl == nil {
+		return func() {}
+	}
+	info, err := fs.Stat(dl.fsys, name)
+	if err != nil {
+		return func() {}
+	}
+	dev, ok := deviceOf(info)
+	if !ok {
+		return func() {}
+	}
+
+	dl.mu.Lock()
+	sem, ok := dl.sems[dev]
+	if !ok {
+		sem = make(chan struct{}, max(dl.limit(dev), 1))
+		if dl.sems == nil {
+			dl.sems = make(map[uint64]chan struct{})
+		}
+		dl.sems[dev] = sem
+	}
+	dl.mu.Unlock()
+
+	sem <- struct{}{}
+	return func() { <-sem }
+}
+
+// parseListLine splits a "digest  path" or "digest *path" line.
+func parseListLine(text string) (sum []byte, path string, err error) {
+	i := strings.IndexByte(text, ' ')
+	if i < 0 || i+1 >= len(text) || (text[i+1] != ' ' && text[i+1] != '*') {
+		return nil, "", fmt.Errorf("whirlpool: malformed list line %q", text)
+	}
+	sum, err = hex.DecodeString(text[:i])
+	if err != nil || len(sum) != digestBytes {
+		return nil, "", fmt.Errorf("whirlpool: malformed digest %q", text[:i])
+	}
+	return sum, text[i+2:], nil
+}
+
+// sumFS returns the whirlpool checksum of the named file in fsys.
+func sumFS(fsys fs.FS, name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d, _, err := SumReader(f)
+	if err != nil {
+		return nil, err
+	}
+	return d[:], nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestVerifyList(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":     {Data: []byte("a")},
+		"dir/b.txt": {Data: []byte("abc")},
+		"bad.txt":   {Data: []byte("not abc")},
+	}
+	list := strings.Join([]string{
+		"# comment",
+		strings.ToLower(golden[1].out) + "  ./a.txt",
+		golden[3].out + " *dir/b.txt",
+		golden[3].out + "  bad.txt",
+		golden[3].out + "  missing.txt",
+		"",
+		"nonsense",
+	}, "\n")
+
+	got := make(map[int]error)
+	for res := range whirlpool.VerifyList(strings.NewReader(list), fsys, 3) {
+		if _, dup := got[res.Line]; dup {
+			t.Fatalf("line %d reported twice", res.Line)
+		}
+		got[res.Line] = res.Err
+	}
+
+	if len(got) != 5 {
+		t.Fatalf("got %d results want 5: %v", len(got), got)
+	}
+	if got[2] != nil || got[3] != nil {
+		t.Fatalf("matching entries failed: %v, %v", got[2], got[3])
+	}
+	if !errors.Is(got[4], whirlpool.ErrMismatch) {
+		t.Fatalf("line 4: got %v want ErrMismatch", got[4])
+	}
+	if !errors.Is(got[5], fs.ErrNotExist) {
+		t.Fatalf("line 5: got %v want fs.ErrNotExist", got[5])
+	}
+	if got[7] == nil {
+		t.Fatalf("line 7: malformed line was accepted")
+	}
+}
+
+// peakFS counts the files open at once and records the highest count. Every
+// file is held open for a moment so that concurrent readers overlap.
+type peakFS struct {
+	fsys fs.FS
+
+	mu         sync.Mutex
+	open, peak int
+}
+
+func (p *peakFS) Open(name string) (fs.File, error) {
+	f, err := p.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.open++
+	p.peak = max(p.peak, p.open)
+	p.mu.Unlock()
+	return &peakFile{File: f, fs: p}, nil
+}
+
+// Stat implements fs.StatFS so that looking up the device of a file does
+// not count as opening it.
+func (p *peakFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(p.fsys, name)
+}
+
+type peakFile struct {
+	fs.File
+	fs *peakFS
+}
+
+func (f *peakFile) Close() error {
+	time.Sleep(time.Millisecond)
+	f.fs.mu.Lock()
+	f.fs.open--
+	f.fs.mu.Unlock()
+	return f.File.Close()
+}
+
+func TestVerifyListLimit(t *testing.T) {
+	dir := t.TempDir()
+	var list strings.Builder
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("f%02d", i)
+		data := bytes.Repeat([]byte{byte(i)}, 1000*i)
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(&list, "%x  %s\n", sum(data), name)
+	}
+
+	var (
+		mu      sync.Mutex
+		devices = make(map[uint64]int)
+	)
+	limit := func(dev uint64) int {
+		mu.Lock()
+		defer mu.Unlock()
+		devices[dev]++
+		return 1
+	}
+	fsys := &peakFS{fsys: os.DirFS(dir)}
+	n := 0
+	for res := range whirlpool.VerifyListLimit(strings.NewReader(list.String()), fsys, 8, limit) {
+		if res.Err != nil {
+			t.Fatalf("%s: %v", res.Path, res.Err)
+		}
+		n++
+	}
+	if n != 20 {
+		t.Fatalf("got %d results want 20", n)
+	}
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		return
+	}
+	if len(devices) != 1 {
+		t.Fatalf("limit called for devices %v, want one device", devices)
+	}
+	for dev, calls := range devices {
+		if calls != 1 {
+			t.Fatalf("limit called %d times for device %d, want once", calls, dev)
+		}
+	}
+	if fsys.peak != 1 {
+		t.Fatalf("%d files open at once with a limit of 1", fsys.peak)
+	}
+}
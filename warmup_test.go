@@ -0,0 +1,40 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func TestWarmup(t *testing.T) {
+	whirlpool.Warmup()
+	for _, g := range golden {
+		if got := fmt.Sprintf("%X", sum([]byte(g.in))); got != g.out {
+			t.Fatalf("after Warmup: sum(%q) = %s want %s", g.in, got, g.out)
+		}
+	}
+}
+
+func TestWarmupConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			whirlpool.Warmup()
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkWarmup(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		whirlpool.Warmup()
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+)
+
+// WatermarkSize is the size of the header written by WatermarkWriter.
+//
+// The header consists of the 8-byte magic "\x89WHIRL\r\n", a version byte
+// (1), an algorithm byte (1 for whirlpool), two reserved zero bytes, the
+// length of the remainder of the artifact as a 64-bit big-endian integer and
+// the whirlpool checksum of the remainder.
+const WatermarkSize = 8 + 4 + 8 + digestBytes
+
+const (
+	watermarkMagic     = "\x89WHIRL\r\n"
+	watermarkVersion   = 1
+	watermarkWhirlpool = 1
+)
+
+// ErrNoWatermark is returned when an artifact does not start with a valid
+// watermark header.
+var ErrNoWatermark = errors.New("whirlpool: missing or unsupported watermark header")
+
+// ErrTrailingData is returned when a watermarked artifact continues past
+// the length declared in its header.
+var ErrTrailingData = errors.New("whirlpool: data after the end of the watermarked artifact")
+
+// WatermarkWriter writes an artifact that starts with a self-describing
+// header holding the checksum of the rest of the artifact.
+type WatermarkWriter struct {
+	ws     io.WriteSeeker
+	start  int64 // Offset of the header.
+	length uint64
+	h      hash.Hash
+}
+
+// NewWatermarkWriter starts a watermarked artifact at the current offset of
+// ws. It reserves room for the header, which is filled in by Close.
+func NewWatermarkWriter(ws io.WriteSeeker) (*WatermarkWriter, error) {
+	start, err := ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ws.Write(make([]byte, WatermarkSize)); err != nil {
+		return nil, err
+	}
+	return &WatermarkWriter{ws: ws, start: start, h: New()}, nil
+}
+
+func (w *WatermarkWriter) Write(p []byte) (int, error) {
+	n, err := w.ws.Write(p)
+	w.h.Write(p[:n])
+	w.length += uint64(n)
+	return n, err
+}
+
+// Close writes the header and leaves the offset of the underlying writer at
+// the end of the artifact. It does not close the underlying writer.
+func (w *WatermarkWriter) Close() error {
+	end, err := w.ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := w.ws.Seek(w.start, io.SeekStart); err != nil {
+		return err
+	}
+
+	hdr := make([]byte, 0, WatermarkSize)
+	hdr = append(hdr, watermarkMagic...)
+	hdr = append(hdr, watermarkVersion, watermarkWhirlpool, 0, 0)
+	hdr = binary.BigEndian.AppendUint64(hdr, w.length)
+	hdr = w.h.Sum(hdr)
+	if _, err := w.ws.Write(hdr); err != nil {
+		return err
+	}
+
+	_, err = w.ws.Seek(end, io.SeekStart)
+	return err
+}
+
+// NewWatermarkReader reads the watermark header from r and returns a reader
+// of the remainder of the artifact. The returned reader reports ErrMismatch
+// instead of io.EOF if the remainder does not match the header, and
+// ErrTrailingData if r holds more data than the header declares. A header
+// whose reserved bytes are not zero is rejected with ErrNoWatermark, since
+// it is either corrupt or from a later revision of the format.
+func NewWatermarkReader(r io.Reader) (io.Reader, error) {
+	var hdr [WatermarkSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrNoWatermark
+		}
+		return nil, err
+	}
+	if string(hdr[:8]) != watermarkMagic || hdr[8] != watermarkVersion || hdr[9] != watermarkWhirlpool {
+		return nil, ErrNoWatermark
+	}
+	if hdr[10] != 0 || hdr[11] != 0 {
+		return nil, ErrNoWatermark
+	}
+	wr := &watermarkReader{
+		r:      r,
+		remain: binary.BigEndian.Uint64(hdr[12:20]),
+		h:      New(),
+	}
+	copy(wr.sum[:], hdr[20:])
+	return wr, nil
+}
+
+// VerifyWatermark reads a whole watermarked artifact from r and checks it
+// against its header.
+func VerifyWatermark(r io.Reader) error {
+	wr, err := NewWatermarkReader(r)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(io.Discard, wr)
+	return err
+}
+
+type watermarkReader struct {
+	r      io.Reader
+	remain uint64
+	sum    [digestBytes]byte
+	h      hash.Hash
+	end    error // Result once the remainder is used up.
+}
+
+func (wr *watermarkReader) Read(p []byte) (int, error) {
+	if wr.remain == 0 {
+		if wr.end == nil {
+			wr.end = wr.finish()
+		}
+		return 0, wr.end
+	}
+	if uint64(len(p)) > wr.remain {
+		p = p[:wr.remain]
+	}
+	n, err := wr.r.Read(p)
+	wr.h.Write(p[:n])
+	wr.remain -= uint64(n)
+	if err == io.EOF {
+		if wr.remain > 0 {
+			return n, io.ErrUnexpectedEOF
+		}
+		err = nil
+	}
+	return n, err
+}
+
+// finish checks the remainder against the header and that nothing follows
+// it.
+func (wr *watermarkReader) finish() error {
+	if !bytes.Equal(wr.h.Sum(nil), wr.sum[:]) {
+		return ErrMismatch
+	}
+	var b [1]byte
+	n, err := io.ReadFull(wr.r, b[:])
+	switch {
+	case n > 0:
+		return ErrTrailingData
+	case err != io.EOF:
+		return err
+	}
+	return io.EOF
+}
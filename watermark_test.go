@@ -0,0 +1,107 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+func writeWatermarked(t *testing.T, body string) []byte {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "artifact"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w, err := whirlpool.NewWatermarkWriter(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(w, body)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if off, _ := f.Seek(0, io.SeekCurrent); off != int64(whirlpool.WatermarkSize+len(body)) {
+		t.Fatalf("offset after Close = %d want %d", off, whirlpool.WatermarkSize+len(body))
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestWatermark(t *testing.T) {
+	body := strings.Repeat("export row\n", 100)
+	data := writeWatermarked(t, body)
+
+	if err := whirlpool.VerifyWatermark(bytes.NewReader(data)); err != nil {
+		t.Fatalf("VerifyWatermark: %v", err)
+	}
+	r, err := whirlpool.NewWatermarkReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil || string(got) != body {
+		t.Fatalf("watermark reader returned %d bytes, %v", len(got), err)
+	}
+
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-1] ^= 1
+	if err := whirlpool.VerifyWatermark(bytes.NewReader(tampered)); err != whirlpool.ErrMismatch {
+		t.Fatalf("tampered artifact: got %v want ErrMismatch", err)
+	}
+	if err := whirlpool.VerifyWatermark(bytes.NewReader(data[:len(data)-1])); err != io.ErrUnexpectedEOF {
+		t.Fatalf("truncated artifact: got %v want io.ErrUnexpectedEOF", err)
+	}
+	if err := whirlpool.VerifyWatermark(strings.NewReader(body)); err != whirlpool.ErrNoWatermark {
+		t.Fatalf("plain file: got %v want ErrNoWatermark", err)
+	}
+	for _, i := range []int{10, 11} {
+		reserved := append([]byte{}, data...)
+		reserved[i] = 1
+		if err := whirlpool.VerifyWatermark(bytes.NewReader(reserved)); err != whirlpool.ErrNoWatermark {
+			t.Fatalf("reserved byte %d set: got %v want ErrNoWatermark", i, err)
+		}
+	}
+}
+
+func TestWatermarkEmpty(t *testing.T) {
+	data := writeWatermarked(t, "")
+	if len(data) != whirlpool.WatermarkSize {
+		t.Fatalf("empty artifact is %d bytes want %d", len(data), whirlpool.WatermarkSize)
+	}
+	if err := whirlpool.VerifyWatermark(bytes.NewReader(data)); err != nil {
+		t.Fatalf("VerifyWatermark: %v", err)
+	}
+}
+
+func TestWatermarkTrailingData(t *testing.T) {
+	for _, body := range []string{"", "export row\n"} {
+		data := append(writeWatermarked(t, body), "garbage"...)
+		if err := whirlpool.VerifyWatermark(bytes.NewReader(data)); err != whirlpool.ErrTrailingData {
+			t.Fatalf("body %q with trailing data: got %v want ErrTrailingData", body, err)
+		}
+		r, err := whirlpool.NewWatermarkReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := io.ReadAll(r); err != whirlpool.ErrTrailingData || string(got) != body {
+			t.Fatalf("body %q: read %q, %v", body, got, err)
+		}
+		if _, err := r.Read(make([]byte, 1)); err != whirlpool.ErrTrailingData {
+			t.Fatalf("second read after the end: got %v", err)
+		}
+	}
+}
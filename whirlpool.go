@@ -9,9 +9,17 @@ package whirlpool
 
 import (
 	"encoding/binary"
+	"errors"
 	"hash"
+	"io"
 )
 
+// Size is the size of a whirlpool checksum in bytes.
+const Size = digestBytes
+
+// BlockSize is the block size of whirlpool in bytes.
+const BlockSize = wblockBytes
+
 // whirlpool represents the partial evaluation of a checksum.
 type whirlpool struct {
 	bitLength  [lengthBytes]byte       // Number of hashed bits.
@@ -19,6 +27,8 @@ type whirlpool struct {
 	bufferBits int                     // Current number of bits on the buffer.
 	bufferPos  int                     // Current byte location on buffer.
 	hash       [digestBytes / 8]uint64 // Hash state.
+	t          *tables                 // Variant; nil for whirlpool.
+	size       int                     // Truncated digest size; 0 for digestBytes.
 }
 
 // New returns a new hash.Hash computing the whirlpool checksum.
@@ -26,7 +36,11 @@ func New() hash.Hash {
 	return new(whirlpool)
 }
 
-// NewRaw ...
+// NewRaw returns a new whirlpool hash with its full method set rather than
+// just hash.Hash: besides the interfaces it implements, State, SetState and
+// Buffered give access to the chaining value and buffered input for
+// checkpointing and research, WriteBits hashes bit strings, SumInto and
+// WriteVectored avoid copies and HexString formats the checksum.
 func NewRaw() *whirlpool {
 	return new(whirlpool)
 }
@@ -44,7 +58,50 @@ func (w *whirlpool) Reset() {
 	w.bitLength = [lengthBytes]byte{}
 }
 
+// State returns the chaining value, that is the hash state after the last
+// complete block, as eight big-endian words.
+func (w *whirlpool) State() [8]uint64 {
+	return w.hash
+}
+
+// SetState replaces the chaining value with h, keeping the bit count and
+// buffered input. Together with State it allows custom checkpointing; a
+// state that did not come from State after the same number of blocks
+// produces a digest of no particular message.
+func (w *whirlpool) SetState(h [8]uint64) {
+	w.hash = h
+}
+
+// Buffered returns a copy of the input that has not yet been compressed,
+// because it does not fill a block, and its length in bits. As with
+// WriteBits, a trailing partial byte holds its bits in the most significant
+// positions.
+func (w *whirlpool) Buffered() (p []byte, nbits int) {
+	return append([]byte(nil), w.buffer[:(w.bufferBits+7)/8]...), w.bufferBits
+}
+
+// Zeroize overwrites the buffered input, the chaining value and the bit
+// count of w with zeros, for callers hashing secrets such as keys or
+// passwords. It is equivalent to Reset, which always wipes the state, and
+// exists to make that intent explicit. Copies that Sum makes on the stack
+// are wiped too, but Go cannot guarantee that no other copy of the state
+// survives in memory.
+func (w *whirlpool) Zeroize() {
+	w.Reset()
+}
+
+// Clone implements hash.Cloner. It returns an independent copy of the
+// running state, so that a common prefix can be hashed once and then
+// continued with different suffixes.
+func (w *whirlpool) Clone() (hash.Cloner, error) {
+	c := *w
+	return &c, nil
+}
+
 func (w *whirlpool) Size() int {
+	if w.size != 0 {
+		return w.size
+	}
 	return digestBytes
 }
 
@@ -52,7 +109,31 @@ func (w *whirlpool) BlockSize() int {
 	return wblockBytes
 }
 
+// variant returns the tables of w.
+func (w *whirlpool) variant() *tables {
+	if w.t == nil {
+		return &whirlpoolTables
+	}
+	return w.t
+}
+
 func (w *whirlpool) transform() {
+	w.variant().compress(&w.hash, &w.buffer)
+}
+
+// Compress applies the whirlpool compression function to the chaining value
+// h and a 64-byte block, updating h in place. It is the Miyaguchi-Preneel
+// construction over the W block cipher that whirlpool iterates, without any
+// padding or length encoding, for building custom modes and test harnesses.
+func Compress(h *[8]uint64, block *[BlockSize]byte) {
+	whirlpoolTables.compress(h, block)
+}
+
+// compress is Compress with the tables of t.
+func (t *tables) compress(hash *[8]uint64, buffer *[wblockBytes]byte) {
+	C0, C1, C2, C3, C4, C5, C6, C7 := t.c[0], t.c[1], t.c[2], t.c[3], t.c[4], t.c[5], t.c[6], t.c[7]
+	RC := t.rc
+
 	var (
 		K     [8]uint64 // Round key.
 		block [8]uint64 // μ(buffer).
@@ -61,24 +142,24 @@ func (w *whirlpool) transform() {
 	)
 
 	// Map the buffer to a block.
-	block[0] = binary.BigEndian.Uint64(w.buffer[0:])
-	block[1] = binary.BigEndian.Uint64(w.buffer[8:])
-	block[2] = binary.BigEndian.Uint64(w.buffer[16:])
-	block[3] = binary.BigEndian.Uint64(w.buffer[24:])
-	block[4] = binary.BigEndian.Uint64(w.buffer[32:])
-	block[5] = binary.BigEndian.Uint64(w.buffer[40:])
-	block[6] = binary.BigEndian.Uint64(w.buffer[48:])
-	block[7] = binary.BigEndian.Uint64(w.buffer[56:])
+	block[0] = binary.BigEndian.Uint64(buffer[0:])
+	block[1] = binary.BigEndian.Uint64(buffer[8:])
+	block[2] = binary.BigEndian.Uint64(buffer[16:])
+	block[3] = binary.BigEndian.Uint64(buffer[24:])
+	block[4] = binary.BigEndian.Uint64(buffer[32:])
+	block[5] = binary.BigEndian.Uint64(buffer[40:])
+	block[6] = binary.BigEndian.Uint64(buffer[48:])
+	block[7] = binary.BigEndian.Uint64(buffer[56:])
 
 	// Compute & apply K^0 to the cipher state.
-	K[0] = w.hash[0]
-	K[1] = w.hash[1]
-	K[2] = w.hash[2]
-	K[3] = w.hash[3]
-	K[4] = w.hash[4]
-	K[5] = w.hash[5]
-	K[6] = w.hash[6]
-	K[7] = w.hash[7]
+	K[0] = hash[0]
+	K[1] = hash[1]
+	K[2] = hash[2]
+	K[3] = hash[3]
+	K[4] = hash[4]
+	K[5] = hash[5]
+	K[6] = hash[6]
+	K[7] = hash[7]
 
 	state[0] = block[0] ^ K[0]
 	state[1] = block[1] ^ K[1]
@@ -90,73 +171,73 @@ func (w *whirlpool) transform() {
 	state[7] = block[7] ^ K[7]
 
 	// Iterate over all the rounds.
-	for r := 1; r <= rounds; r++ {
+	for r := 1; r <= t.rounds; r++ {
 		// Compute K^rounds from K^(rounds-1).
-		L[0] = _C0[byte(K[0%8]>>56)] ^
-			_C1[byte(K[(0+7)%8]>>48)] ^
-			_C2[byte(K[(0+6)%8]>>40)] ^
-			_C3[byte(K[(0+5)%8]>>32)] ^
-			_C4[byte(K[(0+4)%8]>>24)] ^
-			_C5[byte(K[(0+3)%8]>>16)] ^
-			_C6[byte(K[(0+2)%8]>>8)] ^
-			_C7[byte(K[(0+1)%8])]
-		L[1] = _C0[byte(K[1%8]>>56)] ^
-			_C1[byte(K[(1+7)%8]>>48)] ^
-			_C2[byte(K[(1+6)%8]>>40)] ^
-			_C3[byte(K[(1+5)%8]>>32)] ^
-			_C4[byte(K[(1+4)%8]>>24)] ^
-			_C5[byte(K[(1+3)%8]>>16)] ^
-			_C6[byte(K[(1+2)%8]>>8)] ^
-			_C7[byte(K[(1+1)%8])]
-		L[2] = _C0[byte(K[2%8]>>56)] ^
-			_C1[byte(K[(2+7)%8]>>48)] ^
-			_C2[byte(K[(2+6)%8]>>40)] ^
-			_C3[byte(K[(2+5)%8]>>32)] ^
-			_C4[byte(K[(2+4)%8]>>24)] ^
-			_C5[byte(K[(2+3)%8]>>16)] ^
-			_C6[byte(K[(2+2)%8]>>8)] ^
-			_C7[byte(K[(2+1)%8])]
-		L[3] = _C0[byte(K[3%8]>>56)] ^
-			_C1[byte(K[(3+7)%8]>>48)] ^
-			_C2[byte(K[(3+6)%8]>>40)] ^
-			_C3[byte(K[(3+5)%8]>>32)] ^
-			_C4[byte(K[(3+4)%8]>>24)] ^
-			_C5[byte(K[(3+3)%8]>>16)] ^
-			_C6[byte(K[(3+2)%8]>>8)] ^
-			_C7[byte(K[(3+1)%8])]
-		L[4] = _C0[byte(K[4%8]>>56)] ^
-			_C1[byte(K[(4+7)%8]>>48)] ^
-			_C2[byte(K[(4+6)%8]>>40)] ^
-			_C3[byte(K[(4+5)%8]>>32)] ^
-			_C4[byte(K[(4+4)%8]>>24)] ^
-			_C5[byte(K[(4+3)%8]>>16)] ^
-			_C6[byte(K[(4+2)%8]>>8)] ^
-			_C7[byte(K[(4+1)%8])]
-		L[5] = _C0[byte(K[5%8]>>56)] ^
-			_C1[byte(K[(5+7)%8]>>48)] ^
-			_C2[byte(K[(5+6)%8]>>40)] ^
-			_C3[byte(K[(5+5)%8]>>32)] ^
-			_C4[byte(K[(5+4)%8]>>24)] ^
-			_C5[byte(K[(5+3)%8]>>16)] ^
-			_C6[byte(K[(5+2)%8]>>8)] ^
-			_C7[byte(K[(5+1)%8])]
-		L[6] = _C0[byte(K[6%8]>>56)] ^
-			_C1[byte(K[(6+7)%8]>>48)] ^
-			_C2[byte(K[(6+6)%8]>>40)] ^
-			_C3[byte(K[(6+5)%8]>>32)] ^
-			_C4[byte(K[(6+4)%8]>>24)] ^
-			_C5[byte(K[(6+3)%8]>>16)] ^
-			_C6[byte(K[(6+2)%8]>>8)] ^
-			_C7[byte(K[(6+1)%8])]
-		L[7] = _C0[byte(K[7%8]>>56)] ^
-			_C1[byte(K[(7+7)%8]>>48)] ^
-			_C2[byte(K[(7+6)%8]>>40)] ^
-			_C3[byte(K[(7+5)%8]>>32)] ^
-			_C4[byte(K[(7+4)%8]>>24)] ^
-			_C5[byte(K[(7+3)%8]>>16)] ^
-			_C6[byte(K[(7+2)%8]>>8)] ^
-			_C7[byte(K[(7+1)%8])]
-		L[0] ^= rc[r]
+		L[0] = C0[byte(K[0%8]>>56)] ^
+			C1[byte(K[(0+7)%8]>>48)] ^
+			C2[byte(K[(0+6)%8]>>40)] ^
+			C3[byte(K[(0+5)%8]>>32)] ^
+			C4[byte(K[(0+4)%8]>>24)] ^
+			C5[byte(K[(0+3)%8]>>16)] ^
+			C6[byte(K[(0+2)%8]>>8)] ^
+			C7[byte(K[(0+1)%8])]
+		L[1] = C0[byte(K[1%8]>>56)] ^
+			C1[byte(K[(1+7)%8]>>48)] ^
+			C2[byte(K[(1+6)%8]>>40)] ^
+			C3[byte(K[(1+5)%8]>>32)] ^
+			C4[byte(K[(1+4)%8]>>24)] ^
+			C5[byte(K[(1+3)%8]>>16)] ^
+			C6[byte(K[(1+2)%8]>>8)] ^
+			C7[byte(K[(1+1)%8])]
+		L[2] = C0[byte(K[2%8]>>56)] ^
+			C1[byte(K[(2+7)%8]>>48)] ^
+			C2[byte(K[(2+6)%8]>>40)] ^
+			C3[byte(K[(2+5)%8]>>32)] ^
+			C4[byte(K[(2+4)%8]>>24)] ^
+			C5[byte(K[(2+3)%8]>>16)] ^
+			C6[byte(K[(2+2)%8]>>8)] ^
+			C7[byte(K[(2+1)%8])]
+		L[3] = C0[byte(K[3%8]>>56)] ^
+			C1[byte(K[(3+7)%8]>>48)] ^
+			C2[byte(K[(3+6)%8]>>40)] ^
+			C3[byte(K[(3+5)%8]>>32)] ^
+			C4[byte(K[(3+4)%8]>>24)] ^
+			C5[byte(K[(3+3)%8]>>16)] ^
+			C6[byte(K[(3+2)%8]>>8)] ^
+			C7[byte(K[(3+1)%8])]
+		L[4] = C0[byte(K[4%8]>>56)] ^
+			C1[byte(K[(4+7)%8]>>48)] ^
+			C2[byte(K[(4+6)%8]>>40)] ^
+			C3[byte(K[(4+5)%8]>>32)] ^
+			C4[byte(K[(4+4)%8]>>24)] ^
+			C5[byte(K[(4+3)%8]>>16)] ^
+			C6[byte(K[(4+2)%8]>>8)] ^
+			C7[byte(K[(4+1)%8])]
+		L[5] = C0[byte(K[5%8]>>56)] ^
+			C1[byte(K[(5+7)%8]>>48)] ^
+			C2[byte(K[(5+6)%8]>>40)] ^
+			C3[byte(K[(5+5)%8]>>32)] ^
+			C4[byte(K[(5+4)%8]>>24)] ^
+			C5[byte(K[(5+3)%8]>>16)] ^
+			C6[byte(K[(5+2)%8]>>8)] ^
+			C7[byte(K[(5+1)%8])]
+		L[6] = C0[byte(K[6%8]>>56)] ^
+			C1[byte(K[(6+7)%8]>>48)] ^
+			C2[byte(K[(6+6)%8]>>40)] ^
+			C3[byte(K[(6+5)%8]>>32)] ^
+			C4[byte(K[(6+4)%8]>>24)] ^
+			C5[byte(K[(6+3)%8]>>16)] ^
+			C6[byte(K[(6+2)%8]>>8)] ^
+			C7[byte(K[(6+1)%8])]
+		L[7] = C0[byte(K[7%8]>>56)] ^
+			C1[byte(K[(7+7)%8]>>48)] ^
+			C2[byte(K[(7+6)%8]>>40)] ^
+			C3[byte(K[(7+5)%8]>>32)] ^
+			C4[byte(K[(7+4)%8]>>24)] ^
+			C5[byte(K[(7+3)%8]>>16)] ^
+			C6[byte(K[(7+2)%8]>>8)] ^
+			C7[byte(K[(7+1)%8])]
+		L[0] ^= RC[r]
 
 		K[0] = L[0]
 		K[1] = L[1]
@@ -168,70 +249,70 @@ func (w *whirlpool) transform() {
 		K[7] = L[7]
 
 		// Apply r-th round transformation.
-		L[0] = _C0[byte(state[0%8]>>56)] ^
-			_C1[byte(state[(0+7)%8]>>48)] ^
-			_C2[byte(state[(0+6)%8]>>40)] ^
-			_C3[byte(state[(0+5)%8]>>32)] ^
-			_C4[byte(state[(0+4)%8]>>24)] ^
-			_C5[byte(state[(0+3)%8]>>16)] ^
-			_C6[byte(state[(0+2)%8]>>8)] ^
-			_C7[byte(state[(0+1)%8])] ^ K[0%8]
-		L[1] = _C0[byte(state[1%8]>>56)] ^
-			_C1[byte(state[(1+7)%8]>>48)] ^
-			_C2[byte(state[(1+6)%8]>>40)] ^
-			_C3[byte(state[(1+5)%8]>>32)] ^
-			_C4[byte(state[(1+4)%8]>>24)] ^
-			_C5[byte(state[(1+3)%8]>>16)] ^
-			_C6[byte(state[(1+2)%8]>>8)] ^
-			_C7[byte(state[(1+1)%8])] ^ K[1%8]
-		L[2] = _C0[byte(state[2%8]>>56)] ^
-			_C1[byte(state[(2+7)%8]>>48)] ^
-			_C2[byte(state[(2+6)%8]>>40)] ^
-			_C3[byte(state[(2+5)%8]>>32)] ^
-			_C4[byte(state[(2+4)%8]>>24)] ^
-			_C5[byte(state[(2+3)%8]>>16)] ^
-			_C6[byte(state[(2+2)%8]>>8)] ^
-			_C7[byte(state[(2+1)%8])] ^ K[2%8]
-		L[3] = _C0[byte(state[3%8]>>56)] ^
-			_C1[byte(state[(3+7)%8]>>48)] ^
-			_C2[byte(state[(3+6)%8]>>40)] ^
-			_C3[byte(state[(3+5)%8]>>32)] ^
-			_C4[byte(state[(3+4)%8]>>24)] ^
-			_C5[byte(state[(3+3)%8]>>16)] ^
-			_C6[byte(state[(3+2)%8]>>8)] ^
-			_C7[byte(state[(3+1)%8])] ^ K[3%8]
-		L[4] = _C0[byte(state[4%8]>>56)] ^
-			_C1[byte(state[(4+7)%8]>>48)] ^
-			_C2[byte(state[(4+6)%8]>>40)] ^
-			_C3[byte(state[(4+5)%8]>>32)] ^
-			_C4[byte(state[(4+4)%8]>>24)] ^
-			_C5[byte(state[(4+3)%8]>>16)] ^
-			_C6[byte(state[(4+2)%8]>>8)] ^
-			_C7[byte(state[(4+1)%8])] ^ K[4%8]
-		L[5] = _C0[byte(state[5%8]>>56)] ^
-			_C1[byte(state[(5+7)%8]>>48)] ^
-			_C2[byte(state[(5+6)%8]>>40)] ^
-			_C3[byte(state[(5+5)%8]>>32)] ^
-			_C4[byte(state[(5+4)%8]>>24)] ^
-			_C5[byte(state[(5+3)%8]>>16)] ^
-			_C6[byte(state[(5+2)%8]>>8)] ^
-			_C7[byte(state[(5+1)%8])] ^ K[5%8]
-		L[6] = _C0[byte(state[6%8]>>56)] ^
-			_C1[byte(state[(6+7)%8]>>48)] ^
-			_C2[byte(state[(6+6)%8]>>40)] ^
-			_C3[byte(state[(6+5)%8]>>32)] ^
-			_C4[byte(state[(6+4)%8]>>24)] ^
-			_C5[byte(state[(6+3)%8]>>16)] ^
-			_C6[byte(state[(6+2)%8]>>8)] ^
-			_C7[byte(state[(6+1)%8])] ^ K[6%8]
-		L[7] = _C0[byte(state[7%8]>>56)] ^
-			_C1[byte(state[(7+7)%8]>>48)] ^
-			_C2[byte(state[(7+6)%8]>>40)] ^
-			_C3[byte(state[(7+5)%8]>>32)] ^
-			_C4[byte(state[(7+4)%8]>>24)] ^
-			_C5[byte(state[(7+3)%8]>>16)] ^
-			_C6[byte(state[(7+2)%8]>>8)] ^
-			_C7[byte(state[(7+1)%8])] ^ K[7%8]
+		L[0] = C0[byte(state[0%8]>>56)] ^
+			C1[byte(state[(0+7)%8]>>48)] ^
+			C2[byte(state[(0+6)%8]>>40)] ^
+			C3[byte(state[(0+5)%8]>>32)] ^
+			C4[byte(state[(0+4)%8]>>24)] ^
+			C5[byte(state[(0+3)%8]>>16)] ^
+			C6[byte(state[(0+2)%8]>>8)] ^
+			C7[byte(state[(0+1)%8])] ^ K[0%8]
+		L[1] = C0[byte(state[1%8]>>56)] ^
+			C1[byte(state[(1+7)%8]>>48)] ^
+			C2[byte(state[(1+6)%8]>>40)] ^
+			C3[byte(state[(1+5)%8]>>32)] ^
+			C4[byte(state[(1+4)%8]>>24)] ^
+			C5[byte(state[(1+3)%8]>>16)] ^
+			C6[byte(state[(1+2)%8]>>8)] ^
+			C7[byte(state[(1+1)%8])] ^ K[1%8]
+		L[2] = C0[byte(state[2%8]>>56)] ^
+			C1[byte(state[(2+7)%8]>>48)] ^
+			C2[byte(state[(2+6)%8]>>40)] ^
+			C3[byte(state[(2+5)%8]>>32)] ^
+			C4[byte(state[(2+4)%8]>>24)] ^
+			C5[byte(state[(2+3)%8]>>16)] ^
+			C6[byte(state[(2+2)%8]>>8)] ^
+			C7[byte(state[(2+1)%8])] ^ K[2%8]
+		L[3] = C0[byte(state[3%8]>>56)] ^
+			C1[byte(state[(3+7)%8]>>48)] ^
+			C2[byte(state[(3+6)%8]>>40)] ^
+			C3[byte(state[(3+5)%8]>>32)] ^
+			C4[byte(state[(3+4)%8]>>24)] ^
+			C5[byte(state[(3+3)%8]>>16)] ^
+			C6[byte(state[(3+2)%8]>>8)] ^
+			C7[byte(state[(3+1)%8])] ^ K[3%8]
+		L[4] = C0[byte(state[4%8]>>56)] ^
+			C1[byte(state[(4+7)%8]>>48)] ^
+			C2[byte(state[(4+6)%8]>>40)] ^
+			C3[byte(state[(4+5)%8]>>32)] ^
+			C4[byte(state[(4+4)%8]>>24)] ^
+			C5[byte(state[(4+3)%8]>>16)] ^
+			C6[byte(state[(4+2)%8]>>8)] ^
+			C7[byte(state[(4+1)%8])] ^ K[4%8]
+		L[5] = C0[byte(state[5%8]>>56)] ^
+			C1[byte(state[(5+7)%8]>>48)] ^
+			C2[byte(state[(5+6)%8]>>40)] ^
+			C3[byte(state[(5+5)%8]>>32)] ^
+			C4[byte(state[(5+4)%8]>>24)] ^
+			C5[byte(state[(5+3)%8]>>16)] ^
+			C6[byte(state[(5+2)%8]>>8)] ^
+			C7[byte(state[(5+1)%8])] ^ K[5%8]
+		L[6] = C0[byte(state[6%8]>>56)] ^
+			C1[byte(state[(6+7)%8]>>48)] ^
+			C2[byte(state[(6+6)%8]>>40)] ^
+			C3[byte(state[(6+5)%8]>>32)] ^
+			C4[byte(state[(6+4)%8]>>24)] ^
+			C5[byte(state[(6+3)%8]>>16)] ^
+			C6[byte(state[(6+2)%8]>>8)] ^
+			C7[byte(state[(6+1)%8])] ^ K[6%8]
+		L[7] = C0[byte(state[7%8]>>56)] ^
+			C1[byte(state[(7+7)%8]>>48)] ^
+			C2[byte(state[(7+6)%8]>>40)] ^
+			C3[byte(state[(7+5)%8]>>32)] ^
+			C4[byte(state[(7+4)%8]>>24)] ^
+			C5[byte(state[(7+3)%8]>>16)] ^
+			C6[byte(state[(7+2)%8]>>8)] ^
+			C7[byte(state[(7+1)%8])] ^ K[7%8]
 
 		state[0] = L[0]
 		state[1] = L[1]
@@ -244,33 +325,118 @@ func (w *whirlpool) transform() {
 	}
 
 	// Apply the Miyaguchi-Preneel compression function.
-	w.hash[0] ^= state[0] ^ block[0]
-	w.hash[1] ^= state[1] ^ block[1]
-	w.hash[2] ^= state[2] ^ block[2]
-	w.hash[3] ^= state[3] ^ block[3]
-	w.hash[4] ^= state[4] ^ block[4]
-	w.hash[5] ^= state[5] ^ block[5]
-	w.hash[6] ^= state[6] ^ block[6]
-	w.hash[7] ^= state[7] ^ block[7]
+	hash[0] ^= state[0] ^ block[0]
+	hash[1] ^= state[1] ^ block[1]
+	hash[2] ^= state[2] ^ block[2]
+	hash[3] ^= state[3] ^ block[3]
+	hash[4] ^= state[4] ^ block[4]
+	hash[5] ^= state[5] ^ block[5]
+	hash[6] ^= state[6] ^ block[6]
+	hash[7] ^= state[7] ^ block[7]
 }
 
 func (w *whirlpool) Write(source []byte) (int, error) {
-	var (
-		sourcePos  int                                     // Index of the leftmost source.
-		nn         = len(source)                           // Num of bytes to process.
-		sourceBits = uint64(nn * 8)                        // Num of bits to process.
-		sourceGap  = uint((8 - (int(sourceBits & 7))) & 7) // Space on source[sourcePos].
-		bufferRem  = uint(w.bufferBits & 7)                // Occupied bits on buffer[bufferPos].
-		b          uint32                                  // Current byte.
-	)
+	if w.bufferBits&7 == 0 && !forceBitPath {
+		w.addBytes(source)
+	} else {
+		w.addBits(source, uint64(len(source))*8)
+	}
+	return len(source), nil
+}
 
-	// Tally the length of the data added.
-	for i, carry, value := 31, uint32(0), uint64(sourceBits); i >= 0 && (carry != 0 || value != 0); i-- {
+// forceBitPath makes Write use addBits even for byte-aligned input, so that
+// tests keep covering it; see export_test.go.
+var forceBitPath bool
+
+// ReadFrom implements io.ReaderFrom, so that io.Copy into the hash reads
+// into a large pooled buffer and compresses whole blocks straight from it.
+func (w *whirlpool) ReadFrom(r io.Reader) (int64, error) {
+	buf := readBuffers.Get().(*[readBufferSize]byte)
+	defer readBuffers.Put(buf)
+
+	var n int64
+	for {
+		m, err := r.Read(buf[:])
+		w.Write(buf[:m])
+		n += int64(m)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// WriteBits hashes the first nbits bits of p, most significant bit of each
+// byte first, so that messages whose length is not a multiple of 8 bits can
+// be hashed as ISO/IEC 10118-3 allows. It can be mixed freely with Write.
+func (w *whirlpool) WriteBits(p []byte, nbits uint64) error {
+	if nbits > uint64(len(p))*8 {
+		return errors.New("whirlpool: bit count exceeds data length")
+	}
+	full := nbits / 8
+	w.addBits(p[:full], full*8)
+	if k := nbits % 8; k > 0 {
+		// addBits takes a trailing partial byte right-justified.
+		w.addBits([]byte{p[full] >> (8 - k)}, k)
+	}
+	return nil
+}
+
+// addLength adds bits to the number of hashed bits.
+func (w *whirlpool) addLength(bits uint64) {
+	for i, carry, value := 31, uint32(0), bits; i >= 0 && (carry != 0 || value != 0); i-- {
 		carry += uint32(w.bitLength[i]) + (uint32(value & 0xff))
 		w.bitLength[i] = byte(carry)
 		carry >>= 8
 		value >>= 8
 	}
+}
+
+// addBytes hashes source when the buffer holds a whole number of bytes.
+// Whole blocks of source are compressed in place rather than copied into
+// the buffer first.
+func (w *whirlpool) addBytes(source []byte) {
+	w.addLength(uint64(len(source)) * 8)
+	t := w.variant()
+
+	// Complete a partially filled buffer first.
+	if w.bufferPos > 0 {
+		n := copy(w.buffer[w.bufferPos:], source)
+		w.bufferPos += n
+		source = source[n:]
+		if w.bufferPos < wblockBytes {
+			w.bufferBits = 8 * w.bufferPos
+			w.buffer[w.bufferPos] = 0
+			return
+		}
+		t.compress(&w.hash, &w.buffer)
+		w.bufferPos = 0
+	}
+
+	for len(source) >= wblockBytes {
+		t.compress(&w.hash, (*[wblockBytes]byte)(source))
+		source = source[wblockBytes:]
+	}
+
+	// Keep the rest, with the byte after it cleared as addBits expects.
+	w.bufferPos = copy(w.buffer[:], source)
+	w.bufferBits = 8 * w.bufferPos
+	w.buffer[w.bufferPos] = 0
+}
+
+// addBits hashes the last sourceBits bits of source.
+func (w *whirlpool) addBits(source []byte, sourceBits uint64) {
+	var (
+		sourcePos int                                     // Index of the leftmost source.
+		sourceGap = uint((8 - (int(sourceBits & 7))) & 7) // Space on source[sourcePos].
+		bufferRem = uint(w.bufferBits & 7)                // Occupied bits on buffer[bufferPos].
+		b         uint32                                  // Current byte.
+	)
+
+	// Tally the length of the data added.
+	w.addLength(sourceBits)
 
 	// Process data in chunks of 8 bits.
 	for sourceBits > 8 {
@@ -328,57 +494,110 @@ func (w *whirlpool) Write(source []byte) (int, error) {
 		w.buffer[w.bufferPos] = byte(b << (8 - bufferRem))
 		w.bufferBits += int(sourceBits)
 	}
-	return nn, nil
+}
+
+// WriteVectored hashes the concatenation of bufs without copying them into
+// a single buffer, for callers that hold a message in several pieces such as
+// separate header and body buffers. Like Write, it never returns an error.
+func (w *whirlpool) WriteVectored(bufs [][]byte) (int, error) {
+	var n int
+	for _, b := range bufs {
+		w.Write(b)
+		n += len(b)
+	}
+	return n, nil
 }
 
 func (w *whirlpool) Sum(in []byte) []byte {
 	// Copy the whirlpool so that the caller can keep summing.
 	n := *w
+	digest := n.checkSum()
+	n.Reset()
+	return append(in, digest[:w.Size()]...)
+}
+
+// SumInto writes the current checksum to out without changing the
+// underlying hash state and without allocating. On a truncated hash, such
+// as one from New256 or New384, only the first Size() bytes of out hold the
+// checksum, the same bytes Sum returns, and the rest are zeroed.
+func (w *whirlpool) SumInto(out *[Size]byte) {
+	n := *w
+	*out = n.checkSum()
+	n.Reset()
+	clear(out[w.Size():])
+}
+
+// HexString returns the current checksum in lowercase hex, that is the hex
+// of Sum(nil), with the string as the only allocation.
+func (w *whirlpool) HexString() string {
+	var d Digest
+	w.SumInto((*[Size]byte)(&d))
+	return d.hex(w.Size())
+}
+
+// checkSum finalizes w and returns its digest. It modifies w, so Sum calls
+// it on a copy.
+func (w *whirlpool) checkSum() [digestBytes]byte {
+	// Nothing was hashed, so the digest is that of the empty message.
+	if w.t == nil && w.bitLength == [lengthBytes]byte{} && w.hash == [digestBytes / 8]uint64{} {
+		return emptyDigest
+	}
 
 	// Append a 1-bit.
-	n.buffer[n.bufferPos] |= 0x80 >> (uint(n.bufferBits) & 7)
-	n.bufferPos++
+	w.buffer[w.bufferPos] |= 0x80 >> (uint(w.bufferBits) & 7)
+	w.bufferPos++
 
 	// The remaining bits should be 0. Pad with 0s to be complete.
-	if n.bufferPos > wblockBytes-lengthBytes {
-		if n.bufferPos < wblockBytes {
-			for i := 0; i < wblockBytes-n.bufferPos; i++ {
-				n.buffer[n.bufferPos+i] = 0
+	if w.bufferPos > wblockBytes-lengthBytes {
+		if w.bufferPos < wblockBytes {
+			for i := 0; i < wblockBytes-w.bufferPos; i++ {
+				w.buffer[w.bufferPos+i] = 0
 			}
 		}
 		// Process this data block.
-		n.transform()
+		w.transform()
 		// Reset the buffer.
-		n.bufferPos = 0
+		w.bufferPos = 0
 	}
 
-	if n.bufferPos < wblockBytes-lengthBytes {
-		for i := 0; i < (wblockBytes-lengthBytes)-n.bufferPos; i++ {
-			n.buffer[n.bufferPos+i] = 0
+	if w.bufferPos < wblockBytes-lengthBytes {
+		for i := 0; i < (wblockBytes-lengthBytes)-w.bufferPos; i++ {
+			w.buffer[w.bufferPos+i] = 0
 		}
 	}
-	n.bufferPos = wblockBytes - lengthBytes
+	w.bufferPos = wblockBytes - lengthBytes
 
 	// Append the bit length of the hashed data.
 	for i := 0; i < lengthBytes; i++ {
-		n.buffer[n.bufferPos+i] = n.bitLength[i]
+		w.buffer[w.bufferPos+i] = w.bitLength[i]
 	}
 
 	// Process this data block.
-	n.transform()
+	w.transform()
 
 	// Return the final digest as []byte.
 	var digest [digestBytes]byte
 	for i := 0; i < digestBytes/8; i++ {
-		digest[i*8] = byte(n.hash[i] >> 56)
-		digest[i*8+1] = byte(n.hash[i] >> 48)
-		digest[i*8+2] = byte(n.hash[i] >> 40)
-		digest[i*8+3] = byte(n.hash[i] >> 32)
-		digest[i*8+4] = byte(n.hash[i] >> 24)
-		digest[i*8+5] = byte(n.hash[i] >> 16)
-		digest[i*8+6] = byte(n.hash[i] >> 8)
-		digest[i*8+7] = byte(n.hash[i])
+		digest[i*8] = byte(w.hash[i] >> 56)
+		digest[i*8+1] = byte(w.hash[i] >> 48)
+		digest[i*8+2] = byte(w.hash[i] >> 40)
+		digest[i*8+3] = byte(w.hash[i] >> 32)
+		digest[i*8+4] = byte(w.hash[i] >> 24)
+		digest[i*8+5] = byte(w.hash[i] >> 16)
+		digest[i*8+6] = byte(w.hash[i] >> 8)
+		digest[i*8+7] = byte(w.hash[i])
 	}
+	return digest
+}
+
+// Sum512 returns the whirlpool checksum of data.
+func Sum512(data []byte) Digest {
+	var w whirlpool
+	w.Write(data)
+	return w.checkSum()
+}
 
-	return append(in, digest[:digestBytes]...)
+// SumHex returns the whirlpool checksum of data in lowercase hex.
+func SumHex(data []byte) string {
+	return Sum512(data).String()
 }
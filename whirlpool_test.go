@@ -5,11 +5,15 @@
 package whirlpool_test
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"hash"
 	"io"
 	"math/rand"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/tdx/whirlpool"
 
@@ -102,3 +106,359 @@ func BenchmarkWhirlpoolOrig(b *testing.B) {
 func BenchmarkWhirlpoolUnroll(b *testing.B) {
 	benchmarkHashAlgo(b, whirlpool.New())
 }
+
+func TestAllocs(t *testing.T) {
+	h := whirlpool.New()
+	data := make([]byte, 1000)
+	out := make([]byte, 0, h.Size())
+
+	if n := testing.AllocsPerRun(100, func() { h.Write(data) }); n != 0 {
+		t.Errorf("Write: %v allocs, want 0", n)
+	}
+	if n := testing.AllocsPerRun(100, func() { out = h.Sum(out[:0]) }); n != 0 {
+		t.Errorf("Sum into a buffer with capacity: %v allocs, want 0", n)
+	}
+	var digest [whirlpool.Size]byte
+	raw := whirlpool.NewRaw()
+	raw.Write(data)
+	if n := testing.AllocsPerRun(100, func() { raw.SumInto(&digest) }); n != 0 {
+		t.Errorf("SumInto: %v allocs, want 0", n)
+	}
+	if n := testing.AllocsPerRun(100, func() {
+		h.Reset()
+		h.Write(data)
+		out = h.Sum(out[:0])
+	}); n != 0 {
+		t.Errorf("Reset, Write, Sum: %v allocs, want 0", n)
+	}
+}
+
+func BenchmarkWriteSum(b *testing.B) {
+	h := whirlpool.New()
+	data := make([]byte, 2048)
+	out := make([]byte, 0, h.Size())
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		h.Reset()
+		h.Write(data)
+		out = h.Sum(out[:0])
+	}
+}
+
+func TestWriteVectored(t *testing.T) {
+	for _, g := range golden {
+		in := []byte(g.in)
+		// Split unevenly so that pieces straddle block boundaries.
+		var bufs [][]byte
+		for i, size := 0, 1; i < len(in); i, size = i+size, size*3 {
+			end := i + size
+			if end > len(in) {
+				end = len(in)
+			}
+			bufs = append(bufs, in[i:end], nil)
+		}
+
+		h := whirlpool.NewRaw()
+		n, err := h.WriteVectored(bufs)
+		if err != nil || n != len(in) {
+			t.Fatalf("WriteVectored(%q) = %d, %v", g.in, n, err)
+		}
+		if s := fmt.Sprintf("%X", h.Sum(nil)); s != g.out {
+			t.Fatalf("WriteVectored(%q) sum = %s want %s", g.in, s, g.out)
+		}
+	}
+}
+
+func TestSum512(t *testing.T) {
+	for _, g := range golden {
+		if s := whirlpool.Sum512([]byte(g.in)).String(); s != strings.ToLower(g.out) {
+			t.Fatalf("Sum512(%q) = %s want %s", g.in, s, g.out)
+		}
+	}
+	data := make([]byte, 1000)
+	if n := testing.AllocsPerRun(100, func() { whirlpool.Sum512(data) }); n != 0 {
+		t.Errorf("Sum512: %v allocs, want 0", n)
+	}
+}
+
+func TestClone(t *testing.T) {
+	for _, g := range golden {
+		prefix, suffix := g.in[:len(g.in)/2], g.in[len(g.in)/2:]
+		h := whirlpool.New()
+		io.WriteString(h, prefix)
+
+		c, err := h.(hash.Cloner).Clone()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Diverge the original so that a shared state would show.
+		io.WriteString(h, "diverged")
+
+		io.WriteString(c, suffix)
+		if s := fmt.Sprintf("%X", c.Sum(nil)); s != g.out {
+			t.Fatalf("Clone after %q: sum = %s want %s", prefix, s, g.out)
+		}
+		io.WriteString(h, suffix)
+		if s := fmt.Sprintf("%X", h.Sum(nil)); s == g.out {
+			t.Fatalf("original and clone share state")
+		}
+	}
+}
+
+func TestSumInto(t *testing.T) {
+	for _, g := range golden {
+		h := whirlpool.NewRaw()
+		io.WriteString(h, g.in)
+		var out [whirlpool.Size]byte
+		h.SumInto(&out)
+		if s := fmt.Sprintf("%X", out); s != g.out {
+			t.Fatalf("SumInto(%q) = %s want %s", g.in, s, g.out)
+		}
+		// The state is unchanged and can be summed again.
+		if s := fmt.Sprintf("%X", h.Sum(nil)); s != g.out {
+			t.Fatalf("Sum after SumInto(%q) = %s want %s", g.in, s, g.out)
+		}
+	}
+}
+
+var goldenBits = []struct {
+	out   string
+	in    []byte
+	nbits uint64
+}{
+	// Strings of 0-bits, as in the NESSIE set 2 vectors.
+	{"E384D540E0BDFD28C8529177343B31183FB40C20F960B0BCDCE0513A382F96A3832099EBB6AABDB71B0EA2E30177F698EA703DE51F93CF3CFEA6D3171B955383", make([]byte, 1), 1},
+	{"FCA3E2F062017253C68ABFC45C05AB761E15B7350AC2AE347FFFFCC1E0AA09ED5AEAAA2D35BB2EB28A8D3710A52E92A62E11ECB4B2698AF32ED35A31FD6C81E9", make([]byte, 1), 7},
+	{"5ABBC45C92838362F6FB4B9B64DA43B68D2BE1706BEDBBD053C1509B83A532BAB0E74F3CD9ACB5DAA56E25B290F8B444FA75AA501EEA4A82ADAADDB08024561A", make([]byte, 2), 9},
+	{"8432020A603DD464CBA39312AADFCD85D5C1197F960D942F6867190D521E5089C0789B0C60361DAFB0984CB287A1DE7BD9E2240CCE1A592CAF8753A23114E869", make([]byte, 32), 255},
+	{"33A74ADE72B92472447035930455DC111BCD4A2D3C61358695A0D868333025BE2C54121354326083451057944114F99E9AE05FDA919092A78F22C761354AD0FE", make([]byte, 33), 257},
+	{"B9D19DE07ACC38C241D11D8D6FCA817C347875BCA73F5F38CE4E0FF1D3F32D8269666FCD34BBBAC2F24CC63256E6CF9F738A9672A9A8B613C625848CAC4BBE84", make([]byte, 64), 511},
+	{"BA1F1AB4572FED30B77E651B0ECE6FD6C68296E92A8121550B08606FB0DF72C8604D5A593252C27EF985740C27AE43361A439F8E966C3BCF4B757E533E13A4B8", make([]byte, 128), 1023},
+	// Bits are taken most significant first.
+	{"8A487BE22BA5A570E9938EF356FDAF7AF1B67D36EA853A935C4D88E6B0804CB5B3F5C9B423D770218A91A645B08D616A01BBC711242867FD0849B6F5640CE511", []byte{0xa0}, 3},
+	{"4427DD52A88E0E08C3E7EE66AA9356ADECCA0DAE9C864D46CE834B81A0E69F6E1776FB133835E3BE8607ED9185BE8AFFDD8E5F7C8F419F3AB5B484E29724CC06", []byte("abc"), 13},
+	{"B0FDA2C3847497280BF1EE2973DA9CDB337AD2266D91CC6581DDCF36108357068FB046AD6EE7102B6FAF41E2964AA120606F801DE4716A60A2A5946FC6814BB8", []byte("The quick brown fox jumps over the lazy dog"), 341},
+}
+
+func TestWriteBits(t *testing.T) {
+	for _, g := range goldenBits {
+		h := whirlpool.NewRaw()
+		if err := h.WriteBits(g.in, g.nbits); err != nil {
+			t.Fatal(err)
+		}
+		if s := fmt.Sprintf("%X", h.Sum(nil)); s != g.out {
+			t.Fatalf("WriteBits(%x, %d) = %s want %s", g.in, g.nbits, s, g.out)
+		}
+
+		// Feeding the same bits in odd-sized pieces gives the same result.
+		bits := make([]byte, 0, g.nbits)
+		for i := uint64(0); i < g.nbits; i++ {
+			bits = append(bits, g.in[i/8]>>(7-i%8)&1)
+		}
+		h.Reset()
+		for i, size := uint64(0), uint64(1); i < g.nbits; i, size = i+size, size+2 {
+			end := min(i+size, g.nbits)
+			var piece []byte
+			for j := i; j < end; j++ {
+				if (j-i)%8 == 0 {
+					piece = append(piece, 0)
+				}
+				piece[len(piece)-1] |= bits[j] << (7 - (j-i)%8)
+			}
+			h.WriteBits(piece, end-i)
+		}
+		if s := fmt.Sprintf("%X", h.Sum(nil)); s != g.out {
+			t.Fatalf("WriteBits(%x, %d) in pieces = %s want %s", g.in, g.nbits, s, g.out)
+		}
+	}
+
+	// Whole bytes hash as with Write, also after a partial byte.
+	for _, g := range golden {
+		h := whirlpool.NewRaw()
+		h.WriteBits([]byte(g.in), uint64(len(g.in))*8)
+		if s := fmt.Sprintf("%X", h.Sum(nil)); s != g.out {
+			t.Fatalf("WriteBits(%q) = %s want %s", g.in, s, g.out)
+		}
+	}
+	h := whirlpool.NewRaw()
+	h.WriteBits([]byte{0x40}, 3)
+	h.Write([]byte{0x3f, 0xe0})
+	want := whirlpool.NewRaw()
+	want.WriteBits([]byte{0x47, 0xfc}, 14) // 010 00111111 111
+	want.WriteBits([]byte{0}, 5)
+	if !bytes.Equal(h.Sum(nil), want.Sum(nil)) {
+		t.Fatalf("Write after WriteBits of a partial byte mismatch")
+	}
+
+	if err := whirlpool.NewRaw().WriteBits([]byte{0}, 9); err == nil {
+		t.Fatalf("bit count beyond the data accepted")
+	}
+}
+
+func TestZeroize(t *testing.T) {
+	h := whirlpool.NewRaw()
+	io.WriteString(h, "secret key material that spans more than one block of input......")
+	h.Zeroize()
+
+	state, err := h.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, b := range state[4:] {
+		if b != 0 {
+			t.Fatalf("byte %d of the state not wiped: %x", i, state)
+		}
+	}
+	if s := fmt.Sprintf("%X", h.Sum(nil)); s != golden[0].out {
+		t.Fatalf("sum after Zeroize = %s want %s", s, golden[0].out)
+	}
+}
+
+func TestState(t *testing.T) {
+	g := golden[len(golden)-2] // Longer than a block.
+	in := []byte(g.in)
+
+	h := whirlpool.NewRaw()
+	if h.State() != [8]uint64{} {
+		t.Fatalf("initial state not zero")
+	}
+	h.Write(in[:whirlpool.BlockSize+3])
+	if p, n := h.Buffered(); !bytes.Equal(p, in[whirlpool.BlockSize:whirlpool.BlockSize+3]) || n != 24 {
+		t.Fatalf("Buffered() = %q, %d", p, n)
+	}
+
+	// Resume from the chaining value after the first block in a hash that
+	// has seen a different block of the same length.
+	st := h.State()
+	r := whirlpool.NewRaw()
+	r.Write(make([]byte, whirlpool.BlockSize))
+	r.SetState(st)
+	r.Write(in[whirlpool.BlockSize:])
+	if s := fmt.Sprintf("%X", r.Sum(nil)); s != g.out {
+		t.Fatalf("resumed from State = %s want %s", s, g.out)
+	}
+
+	h.Reset()
+	h.WriteBits([]byte{0xa5, 0xf0}, 12)
+	if p, n := h.Buffered(); !bytes.Equal(p, []byte{0xa5, 0xf0}) || n != 12 {
+		t.Fatalf("Buffered() after WriteBits = %x, %d", p, n)
+	}
+}
+
+func TestCompress(t *testing.T) {
+	// Compressing a block matches the chaining value after writing it.
+	var (
+		h     [8]uint64
+		block [whirlpool.BlockSize]byte
+	)
+	whirlpool.Compress(&h, &block)
+
+	raw := whirlpool.NewRaw()
+	raw.Write(block[:])
+	if raw.State() != h {
+		t.Fatalf("Compress = %x want %x", h, raw.State())
+	}
+
+	// The padding of the empty message is 0x80, zeros and a zero length.
+	h, block = [8]uint64{}, [whirlpool.BlockSize]byte{0x80}
+	whirlpool.Compress(&h, &block)
+	var got [whirlpool.Size]byte
+	for i, x := range h {
+		binary.BigEndian.PutUint64(got[8*i:], x)
+	}
+	if s := fmt.Sprintf("%X", got); s != golden[0].out {
+		t.Fatalf("Compress of padded empty message = %s want %s", s, golden[0].out)
+	}
+}
+
+func TestHex(t *testing.T) {
+	for _, g := range golden {
+		want := strings.ToLower(g.out)
+		if s := whirlpool.SumHex([]byte(g.in)); s != want {
+			t.Fatalf("SumHex(%q) = %s want %s", g.in, s, want)
+		}
+		w := whirlpool.NewRaw()
+		w.Write([]byte(g.in))
+		if s := w.HexString(); s != want {
+			t.Fatalf("HexString() of %q = %s want %s", g.in, s, want)
+		}
+	}
+
+	// HexString follows truncation.
+	w := whirlpool.New256().(interface{ HexString() string })
+	if s := w.HexString(); s != strings.ToLower(golden[0].out[:2*whirlpool.Size256]) {
+		t.Fatalf("truncated HexString() = %s", s)
+	}
+
+	data := []byte("abc")
+	if n := testing.AllocsPerRun(10, func() { whirlpool.SumHex(data) }); n > 1 {
+		t.Fatalf("SumHex: got %v allocs want 1", n)
+	}
+}
+
+func TestReadFrom(t *testing.T) {
+	data := seq(0, 300000)
+	w := whirlpool.New()
+	if _, ok := w.(io.ReaderFrom); !ok {
+		t.Fatalf("hash does not implement io.ReaderFrom")
+	}
+	w.Write(data[:3]) // Start with a partially filled buffer.
+	n, err := io.Copy(w, iotest.HalfReader(bytes.NewReader(data[3:])))
+	if err != nil || n != int64(len(data)-3) {
+		t.Fatalf("io.Copy = %d, %v", n, err)
+	}
+	if got, want := w.Sum(nil), whirlpool.Sum512(data); !bytes.Equal(got, want[:]) {
+		t.Fatalf("got %X want %X", got, want)
+	}
+}
+
+func TestWriteSplits(t *testing.T) {
+	data := seq(7, 1000)
+	want := whirlpool.Sum512(data)
+	for _, step := range []int{1, 3, 63, 64, 65, 127, 200} {
+		w := whirlpool.New()
+		for p := data; len(p) > 0; {
+			n := min(step, len(p))
+			w.Write(p[:n])
+			p = p[n:]
+		}
+		if got := w.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Fatalf("writes of %d bytes: got %X want %X", step, got, want)
+		}
+	}
+}
+
+// TestBitPath runs the byte-oriented tests through the bit-oriented path of
+// Write, and checks that both paths agree on random mixes of Write and
+// WriteBits.
+func TestBitPath(t *testing.T) {
+	restore := whirlpool.ForceBitPath(true)
+	t.Run("Golden", TestGolden)
+	t.Run("ReadFrom", TestReadFrom)
+	t.Run("WriteSplits", TestWriteSplits)
+	restore()
+
+	for i := 0; i < 100; i++ {
+		var sums [2][]byte
+		for j, force := range []bool{false, true} {
+			restore := whirlpool.ForceBitPath(force)
+			rnd := rand.New(rand.NewSource(int64(i)))
+			w := whirlpool.NewRaw()
+			for k := 0; k < 20; k++ {
+				p := seq(k, rnd.Intn(150))
+				if rnd.Intn(3) == 0 {
+					w.WriteBits(p, uint64(rnd.Intn(8*len(p)+1)))
+				} else {
+					w.Write(p)
+				}
+			}
+			sums[j] = w.Sum(nil)
+			restore()
+		}
+		if !bytes.Equal(sums[0], sums[1]) {
+			t.Fatalf("seed %d: fast path %X, bit path %X", i, sums[0], sums[1])
+		}
+	}
+}
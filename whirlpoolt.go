@@ -0,0 +1,22 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import (
+	"hash"
+	"sync"
+)
+
+var tTables = sync.OnceValue(func() *tables {
+	return newTables(sbox(), [8]byte{1, 1, 3, 1, 5, 8, 9, 5}, "wht\x01")
+})
+
+// NewT returns a new hash.Hash computing the checksum of Whirlpool-T, the
+// 2001 revision of whirlpool. It has the S-box of whirlpool but the
+// diffusion matrix of the original submission, and is only useful to check
+// digests produced by tools that still implement it.
+func NewT() hash.Hash {
+	return &whirlpool{t: tTables()}
+}
@@ -0,0 +1,58 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+var goldenT = []whirlpoolTest{
+	{"470F0409ABAA446E49667D4EBE12A14387CEDBD10DD17B8243CAD550A089DC0FEEA7AA40F6C2AAAB71C6EBD076E43C7CFCA0AD32567897DCB5969861049A0F5A", ""},
+	{"8AFC0527DCC0A19623860EF2369D0E25DE8EBE2ABAA40F598AFAF6B07C002ED73E4FC0FC220FD4F54F74B5D6B07AA57764C3DBDCC2CDD919D89FA8155A34B841", "abc"},
+	{"3CCF8252D8BBB258460D9AA999C06EE38E67CB546CFFCF48E91F700F6FC7C183AC8CC3D3096DD30A35B01F4620A1E3A20D79CD5168544D9E1B7CDF49970E87F1", "The quick brown fox jumps over the lazy dog"},
+}
+
+func TestGoldenT(t *testing.T) {
+	for _, g := range goldenT {
+		h := whirlpool.NewT()
+		io.WriteString(h, g.in)
+		if s := fmt.Sprintf("%X", h.Sum(nil)); s != g.out {
+			t.Fatalf("whirlpool-T(%q) = %s want %s", g.in, s, g.out)
+		}
+		// Reset keeps the variant.
+		h.Reset()
+		io.WriteString(h, g.in)
+		if s := fmt.Sprintf("%X", h.Sum(nil)); s != g.out {
+			t.Fatalf("whirlpool-T(%q) after Reset = %s want %s", g.in, s, g.out)
+		}
+	}
+}
+
+func TestMarshalBinaryT(t *testing.T) {
+	h := whirlpool.NewT()
+	io.WriteString(h, "The quick brown fox ")
+	state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A Whirlpool-T state cannot be resumed as whirlpool.
+	if err := whirlpool.New().(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err == nil {
+		t.Fatalf("whirlpool accepted a Whirlpool-T state")
+	}
+	r := whirlpool.NewT()
+	if err := r.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(r, "jumps over the lazy dog")
+	if s := fmt.Sprintf("%X", r.Sum(nil)); s != goldenT[2].out {
+		t.Fatalf("resumed whirlpool-T = %s want %s", s, goldenT[2].out)
+	}
+}
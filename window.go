@@ -0,0 +1,97 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool
+
+import "encoding/binary"
+
+// Domain separation prefixes of the Window hash tree.
+const (
+	windowLeaf = 0x00
+	windowNode = 0x01
+	windowRoot = 0x02
+)
+
+// Window maintains a digest over the last n records added to it, for
+// attesting to a sliding window of a record stream such as a log.
+//
+// Records are kept as per-record digests in a ring of n slots, laid out as
+// the leaves of a binary hash tree, so adding a record only recomputes the
+// O(log n) nodes on its path to the root. A record in slot i is the record
+// number k with k mod n == i, counting from zero.
+//
+// A leaf is H(0x00 || record), an unused slot is 64 zero bytes, an inner
+// node is H(0x01 || left || right), with missing leaves beyond n treated as
+// unused slots, and the window digest is H(0x02 || count || root), where
+// count is the total number of records added as a 64-bit big-endian
+// integer.
+type Window struct {
+	n     int
+	size  int // Number of leaves, the smallest power of two >= n.
+	nodes [][digestBytes]byte
+	count uint64
+}
+
+// NewWindow returns a Window over the last n records. It panics if n < 1.
+func NewWindow(n int) *Window {
+	if n < 1 {
+		panic("whirlpool: window size must be positive")
+	}
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+	w := &Window{
+		n:     n,
+		size:  size,
+		nodes: make([][digestBytes]byte, 2*size),
+	}
+	for i := size - 1; i > 0; i-- {
+		w.node(i)
+	}
+	return w
+}
+
+// Add appends a record to the window, evicting the oldest one if the window
+// is full.
+func (w *Window) Add(record []byte) {
+	var h whirlpool
+	h.Write([]byte{windowLeaf})
+	h.Write(record)
+
+	i := w.size + int(w.count%uint64(w.n))
+	h.Sum(w.nodes[i][:0])
+	for i > 1 {
+		i /= 2
+		w.node(i)
+	}
+	w.count++
+}
+
+// node recomputes inner node i from its children.
+func (w *Window) node(i int) {
+	var h whirlpool
+	h.Write([]byte{windowNode})
+	h.Write(w.nodes[2*i][:])
+	h.Write(w.nodes[2*i+1][:])
+	h.Sum(w.nodes[i][:0])
+}
+
+// Count returns the total number of records added to the window.
+func (w *Window) Count() uint64 {
+	return w.count
+}
+
+// Sum returns the digest of the window.
+func (w *Window) Sum() Digest {
+	var (
+		h whirlpool
+		c [8]byte
+	)
+	binary.BigEndian.PutUint64(c[:], w.count)
+	h.Write([]byte{windowRoot})
+	h.Write(c[:])
+	h.Write(w.nodes[1][:])
+	return h.checkSum()
+}
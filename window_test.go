@@ -0,0 +1,71 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package whirlpool_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/tdx/whirlpool"
+)
+
+// windowSum recomputes a Window digest from scratch.
+func windowSum(n int, records [][]byte) whirlpool.Digest {
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+	level := make([][]byte, size)
+	for i := range level {
+		level[i] = make([]byte, 64)
+	}
+	for k, r := range records {
+		level[k%n] = sum(append([]byte{0}, r...))
+	}
+	for len(level) > 1 {
+		next := make([][]byte, len(level)/2)
+		for i := range next {
+			next[i] = sum(append(append([]byte{1}, level[2*i]...), level[2*i+1]...))
+		}
+		level = next
+	}
+	var c [8]byte
+	binary.BigEndian.PutUint64(c[:], uint64(len(records)))
+	return whirlpool.Sum512(append(append([]byte{2}, c[:]...), level[0]...))
+}
+
+func TestWindow(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 5, 8} {
+		w := whirlpool.NewWindow(n)
+		var records [][]byte
+		for k := 0; k < 3*n+1; k++ {
+			r := []byte(fmt.Sprintf("record %d", k))
+			records = append(records, r)
+			w.Add(r)
+			if got, want := w.Sum(), windowSum(n, records); got != want {
+				t.Fatalf("n=%d after %d records: got %s want %s", n, k+1, got, want)
+			}
+		}
+		if w.Count() != uint64(len(records)) {
+			t.Fatalf("Count() = %d want %d", w.Count(), len(records))
+		}
+	}
+}
+
+func TestWindowEvicts(t *testing.T) {
+	a := whirlpool.NewWindow(2)
+	b := whirlpool.NewWindow(2)
+	a.Add([]byte("old"))
+	b.Add([]byte("other"))
+	for _, r := range []string{"x", "y"} {
+		a.Add([]byte(r))
+		b.Add([]byte(r))
+	}
+	// Both windows now hold the same last two records in the same slots.
+	if a.Sum() != b.Sum() {
+		t.Fatalf("evicted records still affect the window digest")
+	}
+}